@@ -22,13 +22,11 @@ import (
 	"os"
 	"path"
 	"path/filepath"
-	"plugin"
 	"regexp"
 	"strings"
 
 	gogetter "github.com/hashicorp/go-getter"
 	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
-	kfdefs "github.com/opendatahub-io/opendatahub-operator/apis/kfdef.apps.kubeflow.org/v1"
 	log "github.com/sirupsen/logrus"
 	ext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	crdclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -163,6 +161,7 @@ const (
 	GCP              = "gcp"
 	MINIKUBE         = "minikube"
 	EXISTING_ARRIKTO = "existing_arrikto"
+	OPENSHIFT        = "openshift"
 )
 
 // PackageManagers
@@ -171,29 +170,6 @@ const (
 	KUSTOMIZE = "kustomize"
 )
 
-// LoadKfApp will load a shared library of the form <name>.so
-func LoadKfApp(name string, kfdef *kfdefs.KfDef) (KfApp, error) {
-	pluginname := strings.Replace(name, "-", "", -1)
-	plugindir := os.Getenv("PLUGINS_ENVIRONMENT")
-	pluginpath := filepath.Join(plugindir, name+".so")
-	p, err := plugin.Open(pluginpath)
-	if err != nil {
-		return nil, &kfapis.KfError{
-			Code:    int(kfapis.INVALID_ARGUMENT),
-			Message: fmt.Sprintf("could not load plugin %v for platform %v: %v", pluginpath, pluginname, err),
-		}
-	}
-	symName := "GetKfApp"
-	symbol, symbolErr := p.Lookup(symName)
-	if symbolErr != nil {
-		return nil, &kfapis.KfError{
-			Code:    int(kfapis.INTERNAL_ERROR),
-			Message: fmt.Sprintf("could not find symbol %v for platform %v: %v", symName, pluginname, symbolErr),
-		}
-	}
-	return symbol.(func(*kfdefs.KfDef) KfApp)(kfdef), nil
-}
-
 // DownloadToCache will download a version of kubeflow github repo or the manifests repo where version can be
 //   master
 //	 tag
@@ -39,6 +39,28 @@ type KfDefSpec struct {
 	Plugins      []Plugin      `json:"plugins,omitempty"`
 	Secrets      []Secret      `json:"secrets,omitempty"`
 	Repos        []Repo        `json:"repos,omitempty"`
+	// Images overrides images of all the applications, in the containers and init containers of
+	// their resources and in the parameters set to one of the images.
+	Images []Image `json:"images,omitempty"`
+	// Patches are applied to the rendered resources of all the applications. They are reported
+	// in the status if they don't match any resource.
+	Patches []Patch `json:"patches,omitempty"`
+	// CommonLabels are added to the resources of all the applications and to the pod templates of
+	// their workloads. They're never added to selectors, so the selectors of existing workloads
+	// don't change.
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+	// CommonAnnotations are added to the resources of all the applications.
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
+	// ImagePullSecrets are added to the pod templates of the workloads and to the ServiceAccounts
+	// of all the applications.
+	ImagePullSecrets []ImagePullSecret `json:"imagePullSecrets,omitempty"`
+	// ImagePullPolicy replaces the image pull policy of the containers of the workloads of all the applications.
+	ImagePullPolicy v1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// Proxy injects proxy settings and a trusted CA bundle into the workloads of all the applications.
+	Proxy *Proxy `json:"proxy,omitempty"`
+	// StrictParameters fails the apply if an application sets parameters which aren't declared
+	// in the params.env of its kustomize package. They are only reported in the status otherwise.
+	StrictParameters bool `json:"strictParameters,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -66,6 +88,114 @@ type KfDefList struct {
 type Application struct {
 	Name            string           `json:"name,omitempty"`
 	KustomizeConfig *KustomizeConfig `json:"kustomizeConfig,omitempty"`
+	// HelmConfig renders the application from a Helm chart instead of a kustomize package.
+	HelmConfig *HelmConfig `json:"helmConfig,omitempty"`
+	// ManifestsConfig reads the application from a directory of plain YAML manifests instead of a kustomize package.
+	ManifestsConfig *ManifestsConfig `json:"manifestsConfig,omitempty"`
+	// Images overrides images of the application, taking precedence over the Images of the KfDef.
+	Images []Image `json:"images,omitempty"`
+	// Patches are applied to the rendered resources of the application, after the Patches of the KfDef.
+	Patches []Patch `json:"patches,omitempty"`
+	// CommonLabels are added to the resources of the application like the CommonLabels of the
+	// KfDef, taking precedence over them.
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+	// CommonAnnotations are added to the resources of the application, taking precedence over
+	// the CommonAnnotations of the KfDef.
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
+	// ImagePullSecrets are added to the pod templates of the workloads and to the ServiceAccounts of
+	// the application, replacing the ImagePullSecrets of the KfDef with the same name.
+	ImagePullSecrets []ImagePullSecret `json:"imagePullSecrets,omitempty"`
+	// ImagePullPolicy replaces the image pull policy of the containers of the workloads of the
+	// application, taking precedence over the ImagePullPolicy of the KfDef.
+	ImagePullPolicy v1.PullPolicy `json:"imagePullPolicy,omitempty"`
+	// Placement is applied to the pod templates of the workloads of the application.
+	Placement *Placement `json:"placement,omitempty"`
+	// HardenPodSecurity makes the workloads of the application meet the restricted Pod Security
+	// Standard where their security context doesn't say otherwise: their pods run as non root with
+	// the RuntimeDefault seccomp profile, and their containers drop all capabilities and disallow
+	// privilege escalation. Workloads annotated with opendatahub.io/skip-pod-security-hardening: "true"
+	// are left alone, as are Pods and Jobs, whose pod spec is immutable, and workloads with privileged
+	// containers or containers adding CAP_SYS_ADMIN, which are reported as warnings.
+	HardenPodSecurity bool `json:"hardenPodSecurity,omitempty"`
+	// Resources overrides the compute resources of the containers of the workloads of the
+	// application, by container name. The requests and limits set replace the ones of the containers.
+	Resources map[string]v1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// Image overrides the name, tag or digest of an image, like the images of a kustomization.
+type Image struct {
+	// Name of the image to override, without its tag or digest.
+	Name string `json:"name,omitempty"`
+	// NewName replaces the name, e.g. with the one of the image in a mirror registry.
+	NewName string `json:"newName,omitempty"`
+	// NewTag replaces the tag.
+	NewTag string `json:"newTag,omitempty"`
+	// Digest replaces the tag and takes precedence over NewTag.
+	Digest string `json:"digest,omitempty"`
+}
+
+// ImagePullSecret is a Secret the pods of the workloads pull their images with.
+type ImagePullSecret struct {
+	// Name of the Secret, in the namespaces of the workloads.
+	Name string `json:"name,omitempty"`
+	// CopyFromNamespace copies the Secret with this name from the namespace into the namespaces of
+	// the workloads and ServiceAccounts, once their application is applied. It must be the namespace
+	// of the KfDef. The copies are deleted along with the KfDef.
+	CopyFromNamespace string `json:"copyFromNamespace,omitempty"`
+}
+
+// Proxy injects proxy settings and a trusted CA bundle into the containers of the workloads of
+// all the applications.
+type Proxy struct {
+	// HTTPProxy, HTTPSProxy and NoProxy are set as the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables of the containers, in upper and lower case.
+	HTTPProxy  string `json:"httpProxy,omitempty"`
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+	NoProxy    string `json:"noProxy,omitempty"`
+	// FromCluster loads the settings of the cluster wide proxy into the containers, from the
+	// ConfigMap the clusterProxy of the OpenShift plugin writes them to. The settings above take
+	// precedence over them.
+	FromCluster bool `json:"fromCluster,omitempty"`
+	// TrustedCABundle is the ConfigMap holding the trusted CA bundle in its ca-bundle.crt key,
+	// mounted in the containers as /etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem. It replaces
+	// the bundle of the system CAs, so it must include them, like the cluster trusted CA bundle does,
+	// and the containers don't start until it exists. It defaults to the ConfigMap the OpenShift
+	// plugin injects the cluster trusted CA bundle into with FromCluster.
+	TrustedCABundle string `json:"trustedCABundle,omitempty"`
+}
+
+// Placement schedules the pods of the workloads of an application, e.g. on dedicated node pools.
+type Placement struct {
+	// NodeSelector is merged into the node selector of the pods, taking precedence over it.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations are added to the tolerations of the pods.
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+	// Affinity replaces the affinity of the pods.
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+	// PriorityClassName replaces the priority class of the pods.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// Patch is a strategic merge or JSON 6902 patch applied to the rendered resources it targets.
+type Patch struct {
+	// Patch is the strategic merge patch, or the list of JSON 6902 operations, in YAML or JSON.
+	// A strategic merge patch doesn't need an apiVersion, kind or name, they're the ones of each
+	// resource patched.
+	Patch string `json:"patch,omitempty"`
+	// Target selects the resources to patch.
+	Target *PatchTarget `json:"target,omitempty"`
+}
+
+// PatchTarget selects resources by group, version, kind, name, namespace and labels.
+// Fields left empty match any resource. Name and Namespace are regular expressions, as in the
+// targets of kustomize patches, and LabelSelector is a label selector expression.
+type PatchTarget struct {
+	Group         string `json:"group,omitempty"`
+	Version       string `json:"version,omitempty"`
+	Kind          string `json:"kind,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	LabelSelector string `json:"labelSelector,omitempty"`
 }
 
 type KustomizeConfig struct {
@@ -74,6 +204,52 @@ type KustomizeConfig struct {
 	Parameters []NameValue `json:"parameters,omitempty"`
 }
 
+// ManifestsConfig provides a directory of YAML manifests to apply as is, or after rendering them
+// as Go templates.
+type ManifestsConfig struct {
+	// RepoRef points to the directory of manifests in one of the repos. Files ending in .yaml or .yml
+	// are read, including those in subdirectories.
+	RepoRef *RepoRef `json:"repoRef,omitempty"`
+	// Template renders each file with text/template before it is applied. The template data has
+	// the Name and Namespace of the KfDef and the Parameters by name, e.g. {{ .Parameters.replicas }}.
+	Template bool `json:"template,omitempty"`
+	// Parameters are the values available to the templates.
+	Parameters []NameValue `json:"parameters,omitempty"`
+}
+
+// HelmConfig provides the chart and values used to render an application with Helm.
+// The chart is either a directory in one of the repos, given by RepoRef, or pulled from
+// ChartRepo or an OCI registry. The hooks of the chart are applied along with its other
+// resources, except its test hooks, which are skipped.
+type HelmConfig struct {
+	// RepoRef points to a chart directory in one of the repos.
+	RepoRef *RepoRef `json:"repoRef,omitempty"`
+	// ChartRepo is the URL of the chart repository to pull Chart from.
+	ChartRepo string `json:"chartRepo,omitempty"`
+	// Chart is the name of the chart in ChartRepo, or an oci:// chart reference.
+	Chart string `json:"chart,omitempty"`
+	// Version of the chart to pull. Defaults to the latest version.
+	Version string `json:"version,omitempty"`
+	// Values override the chart defaults and any values read from ValuesFrom.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Values *runtime.RawExtension `json:"values,omitempty"`
+	// ValuesFrom lists ConfigMaps and Secrets in the namespace of the application holding values,
+	// merged in order.
+	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
+}
+
+// ValuesReference selects a key of a ConfigMap or Secret holding Helm values in YAML.
+type ValuesReference struct {
+	// Kind of the object, ConfigMap or Secret.
+	Kind string `json:"kind,omitempty"`
+	// Name of the object.
+	Name string `json:"name,omitempty"`
+	// Key holding the values. Defaults to values.yaml.
+	Key string `json:"key,omitempty"`
+	// Optional ignores a missing object or key.
+	Optional bool `json:"optional,omitempty"`
+}
+
 type RepoRef struct {
 	Name string `json:"name,omitempty"`
 	Path string `json:"path,omitempty"`
@@ -82,6 +258,15 @@ type RepoRef struct {
 type NameValue struct {
 	Name  string `json:"name,omitempty"`
 	Value string `json:"value,omitempty"`
+	// ValueFrom reads the value from a key of a ConfigMap or Secret when the KfDef is applied,
+	// instead of setting it in Value.
+	ValueFrom *ValueSource `json:"valueFrom,omitempty"`
+}
+
+// ValueSource selects the ConfigMap or Secret key holding the value of a parameter.
+type ValueSource struct {
+	SecretKeyRef    *ObjectKeyRef `json:"secretKeyRef,omitempty"`
+	ConfigMapKeyRef *ObjectKeyRef `json:"configMapKeyRef,omitempty"`
 }
 
 // Plugin can be used to customize the generation and deployment of Kubeflow
@@ -102,6 +287,10 @@ type Secret struct {
 type SecretSource struct {
 	LiteralSource *LiteralSource `json:"literalSource,omitempty"`
 	EnvSource     *EnvSource     `json:"envSource,omitempty"`
+	// SecretKeyRef reads the value from a key of a Secret when the KfDef is applied.
+	SecretKeyRef *ObjectKeyRef `json:"secretKeyRef,omitempty"`
+	// ConfigMapKeyRef reads the value from a key of a ConfigMap when the KfDef is applied.
+	ConfigMapKeyRef *ObjectKeyRef `json:"configMapKeyRef,omitempty"`
 }
 
 type LiteralSource struct {
@@ -112,6 +301,15 @@ type EnvSource struct {
 	Name string `json:"name,omitempty"`
 }
 
+// ObjectKeyRef selects a key of a ConfigMap or Secret.
+// The KfDef is reconciled again when the object changes.
+type ObjectKeyRef struct {
+	// Namespace of the object. Defaults to the namespace of the KfDef, which is the only one allowed.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
 // SecretRef is a reference to a secret
 type SecretRef struct {
 	// Name of the secret
@@ -126,7 +324,26 @@ type Repo struct {
 	// URI where repository can be obtained.
 	// Can use any URI understood by go-getter:
 	// https://github.com/hashicorp/go-getter/blob/master/README.md#installation-and-usage
+	// Git repositories (git::, ssh:// or *.git URIs) and OCI artifacts
+	// (oci://registry/repository:tag or oci://registry/repository@sha256:digest) are also supported,
+	// as are ConfigMaps and Secrets (configmap://namespace/name or secret://namespace/name) holding
+	// a manifests tarball or a set of files. They must be in the namespace of the KfDef.
 	URI string `json:"uri,omitempty"`
+	// Ref is the branch, tag or commit SHA to check out when URI points to a git repository.
+	// Defaults to the remote HEAD.
+	Ref string `json:"ref,omitempty"`
+	// SecretRef names a Secret in the KfDef namespace holding credentials used to fetch the repo.
+	// The keys username and password are used for basic auth, token for bearer auth and ca.crt
+	// as a PEM encoded CA bundle to trust in addition to the system roots.
+	SecretRef *SecretRef `json:"secretRef,omitempty"`
+	// Proxy is the URL of the proxy used to fetch the repo, overriding the proxy environment variables.
+	Proxy string `json:"proxy,omitempty"`
+	// Timeout for fetching the repo. There is no timeout by default.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// PollInterval is how often the operator checks whether the repo source changed, comparing
+	// its commit, digest or ETag with the resolved revision, and reapplies the KfDef if it did.
+	// The repo isn't polled by default.
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
 }
 
 // KfDefStatus defines the observed state of KfDef
@@ -134,11 +351,18 @@ type KfDefStatus struct {
 	Conditions []KfDefCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 	// ReposCache is used to cache information about local caching of the URIs.
 	ReposCache []RepoCache `json:"reposCache,omitempty"`
+	// RenderedKinds are the kinds of the resources rendered for the applications at the last apply.
+	// The operator watches them for changes, along with the kinds it always watches.
+	RenderedKinds []metav1.GroupVersionKind `json:"renderedKinds,omitempty"`
 }
 
 type RepoCache struct {
 	Name      string `json:"name,omitempty"`
-	LocalPath string `json:"localPath,string"`
+	LocalPath string `json:"localPath,omitempty"`
+	// ResolvedRevision is the revision the repo was fetched at, e.g. the commit SHA for git repositories.
+	ResolvedRevision string `json:"resolvedRevision,omitempty"`
+	// LastCheckedTime is the last time the repo source was fetched or checked for changes.
+	LastCheckedTime *metav1.Time `json:"lastCheckedTime,omitempty"`
 }
 
 type KfDefConditionType string
@@ -21,26 +21,18 @@ import (
 	"fmt"
 	"github.com/ghodss/yaml"
 	"github.com/go-logr/logr"
-	ocappsv1 "github.com/openshift/api/apps/v1"
-	ocbuildv1 "github.com/openshift/api/build/v1"
-	ocimgv1 "github.com/openshift/api/image/v1"
 	"io/ioutil"
-	admv1 "k8s.io/api/admissionregistration/v1"
-	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
-	netv1 "k8s.io/api/networking/v1"
-	rbacv1 "k8s.io/api/rbac/v1"
-	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
-	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
 	"os"
 	"path"
+	"reflect"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -91,6 +83,10 @@ type KfDefReconciler struct {
 	Log        logr.Logger
 	// Recorder to generate events
 	Recorder record.EventRecorder
+	// watches watches the kinds rendered for the KfDefs which aren't always watched
+	watches *dynamicWatches
+	// pending records the applications to render at the next reconcile of the KfDefs
+	pending *pendingApplications
 }
 
 //+kubebuilder:rbac:groups=*,resources=*,verbs=*
@@ -163,7 +159,9 @@ func (r *KfDefReconciler) Reconcile(ctx context.Context, request ctrl.Request) (
 		r.Log.Info("kfAppDir deleted.")
 
 		// Remove this KfDef instance
-		delete(kfdefInstances, strings.Join([]string{instance.GetName(), instance.GetNamespace()}, "."))
+		delete(kfdefInstances, kfutils.KfDefInstanceKey(instance.GetName(), instance.GetNamespace()))
+		r.pending.forget(request.NamespacedName)
+		r.watches.forget(request.NamespacedName)
 
 		// Remove finalizer once kfDelete is completed.
 		finalizers.Delete(finalizer)
@@ -209,9 +207,7 @@ func (r *KfDefReconciler) Reconcile(ctx context.Context, request ctrl.Request) (
 
 	if hasDeleteConfigMap(r.Client) {
 		for key, _ := range kfdefInstances {
-			keyVal := strings.Split(key, ".")
-			if len(keyVal) == 2 {
-				instanceName, namespace := keyVal[0], keyVal[1]
+			if instanceName, namespace, ok := kfutils.ParseKfDefInstanceKey(key); ok {
 				currentInstance := &kfdefappskubefloworgv1.KfDef{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      instanceName,
@@ -233,19 +229,28 @@ func (r *KfDefReconciler) Reconcile(ctx context.Context, request ctrl.Request) (
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	err = getReconcileStatus(instance, kfApply(instance))
+	// only the applications referencing the objects which changed are rendered, if nothing else did
+	applications := r.pending.take(request.NamespacedName, instance.Generation)
+	applyErr := kfApply(instance, applications)
+	r.pending.applied(request.NamespacedName, instance.Generation, applications, applyErr)
+	err = getReconcileStatus(instance, applyErr)
 	if err == nil {
 		r.Log.Info("KubeFlow Deployment Completed.")
 		r.Recorder.Eventf(instance, v1.EventTypeNormal, "KfDefCreationSuccessful",
 			"KfDef instance %s created and deployed successfully", instance.Name)
 
 		// add to kfdefInstances if not exists
-		if _, ok := kfdefInstances[strings.Join([]string{instance.GetName(), instance.GetNamespace()}, ".")]; !ok {
-			kfdefInstances[strings.Join([]string{instance.GetName(), instance.GetNamespace()}, ".")] = struct{}{}
+		if _, ok := kfdefInstances[kfutils.KfDefInstanceKey(instance.GetName(), instance.GetNamespace())]; !ok {
+			kfdefInstances[kfutils.KfDefInstanceKey(instance.GetName(), instance.GetNamespace())] = struct{}{}
 		}
 
 	}
 
+	// watch the kinds rendered at the last successful apply
+	if err := r.watches.watchKfDef(request.NamespacedName, instance.Status.RenderedKinds); err != nil {
+		r.Log.Error(err, "failed to watch the rendered kinds")
+	}
+
 	// set status of the KfDef resource
 	if err := r.reconcileStatus(instance); err != nil {
 		return ctrl.Result{}, err
@@ -260,38 +265,65 @@ func (r *KfDefReconciler) Reconcile(ctx context.Context, request ctrl.Request) (
 func (r *KfDefReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Log.Info("Adding controller for kfdef.")
 
-	watchKfdefHandler := handler.EnqueueRequestsFromMapFunc(r.watchKfDef)
-	watchedHandler := handler.EnqueueRequestsFromMapFunc(r.watchKubeflowResources)
+	// Every change but those of the referenced objects renders all the applications.
+	r.pending = newPendingApplications()
+	watchKfdefHandler := r.pending.renderAll(handler.EnqueueRequestsFromMapFunc(r.watchKfDef))
+	managedHandler := r.pending.renderAll(handler.EnqueueRequestsFromMapFunc(r.watchKubeflowResources))
+	deleteConfigMapHandler := r.pending.renderAll(handler.EnqueueRequestsFromMapFunc(r.watchDeleteConfigMap))
+	ownedHandler := r.pending.renderAll(&handler.EnqueueRequestForOwner{
+		OwnerType: &kfdefappskubefloworgv1.KfDef{}, IsController: true})
+	referencedHandler := handler.EnqueueRequestsFromMapFunc(r.watchReferencedObjects)
+
+	if err := mgr.GetFieldIndexer().IndexField(context.TODO(), &kfdefappskubefloworgv1.KfDef{},
+		referencedObjectsIndex, referencedObjectKeys); err != nil {
+		return err
+	}
 
-	err := ctrl.NewControllerManagedBy(mgr).Named("kfdef-controller").
-		For(&kfdefappskubefloworgv1.KfDef{}).
+	poller := newRepoPoller(r.Client, r.Log.WithName("repo-poller"))
+	if err := mgr.Add(poller); err != nil {
+		return err
+	}
+
+	deleteConfigMapPredicates, err := predicate.LabelSelectorPredicate(metav1.LabelSelector{
+		MatchLabels: map[string]string{deleteConfigMapLabel: "true"}})
+	if err != nil {
+		return err
+	}
+	b := ctrl.NewControllerManagedBy(mgr).Named("kfdef-controller").
+		For(&kfdefappskubefloworgv1.KfDef{}, builder.WithPredicates(kfdefSpecPredicates)).
 		Watches(&source.Kind{Type: &kfdefappskubefloworgv1.KfDef{}}, watchKfdefHandler, builder.WithPredicates(kfdefPredicates)).
-		Watches(&source.Kind{Type: &appsv1.Deployment{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &v1.Namespace{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &v1.PersistentVolumeClaim{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &v1.Service{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &appsv1.DaemonSet{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &appsv1.StatefulSet{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &ocappsv1.DeploymentConfig{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &ocimgv1.ImageStream{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &ocbuildv1.BuildConfig{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &apiextensionsv1.CustomResourceDefinition{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &apiregistrationv1.APIService{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &netv1.Ingress{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &admv1.MutatingWebhookConfiguration{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &admv1.ValidatingWebhookConfiguration{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &v1.Secret{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &v1.ConfigMap{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &v1.ServiceAccount{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &rbacv1.Role{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &rbacv1.RoleBinding{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &rbacv1.ClusterRole{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Watches(&source.Kind{Type: &rbacv1.ClusterRoleBinding{}}, watchedHandler, builder.WithPredicates(ownedResourcePredicates)).
-		Complete(r)
+		Watches(&source.Channel{Source: poller.events}, r.pending.renderAll(&handler.EnqueueRequestForObject{})).
+		Watches(&source.Kind{Type: &v1.ConfigMap{}}, deleteConfigMapHandler, builder.WithPredicates(deleteConfigMapPredicates)).
+		Watches(&source.Kind{Type: &v1.ConfigMap{}}, referencedHandler, builder.WithPredicates(referencedObjectPredicates)).
+		Watches(&source.Kind{Type: &v1.Secret{}}, referencedHandler, builder.WithPredicates(referencedObjectPredicates))
+	// The resources in the namespace of their KfDef are watched by their controller reference, as
+	// Owns() does, so they are watched even when the KfDef can't label them. Owns() enqueues the
+	// KfDef without rendering all its applications, hence the wrapped handler.
+	for _, obj := range namespacedResourceTypes() {
+		b = b.Watches(&source.Kind{Type: obj}, ownedHandler, builder.WithPredicates(managedResourcePredicates))
+	}
+	c, err := b.Build(r)
+	if err != nil {
+		return err
+	}
 
+	// The other managed resources, cluster-scoped or in other namespaces, are watched by their
+	// ownership labels, the kinds always managed from the start.
+	metadataClient, err := metadata.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	r.watches = newDynamicWatches(c, mgr.GetRESTMapper(), metadataClient, managedHandler)
+	if err := mgr.Add(r.watches); err != nil {
+		return err
+	}
+	kinds, err := watchedKinds(mgr.GetScheme(), append(namespacedResourceTypes(), clusterResourceTypes()...))
 	if err != nil {
 		return err
 	}
+	if err := r.watches.watch(kinds); err != nil {
+		return err
+	}
 	kfdefLog = r.Log
 	return nil
 }
@@ -324,38 +356,49 @@ func (r *KfDefReconciler) watchKfDef(a client.Object) (requests []reconcile.Requ
 
 // watch is monitoring changes for kfctl resources managed by the operator
 func (r *KfDefReconciler) watchKubeflowResources(a client.Object) (requests []reconcile.Request) {
-	anns := a.GetAnnotations()
-	kfdefAnn := strings.Join([]string{kfutils.KfDefAnnotation, kfutils.KfDefInstance}, "/")
-	_, found := anns[kfdefAnn]
-	if found {
-		kfdefCr := strings.Split(anns[kfdefAnn], ".")
-		namespacedName := types.NamespacedName{Name: kfdefCr[0], Namespace: kfdefCr[1]}
-		instance := &kfdefappskubefloworgv1.KfDef{}
-		err := r.Client.Get(context.TODO(), types.NamespacedName{Name: kfdefCr[0], Namespace: kfdefCr[1]}, instance)
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// KfDef CR may have been deleted
-				return nil
-			}
-		} else if instance.GetDeletionTimestamp() != nil {
-			// KfDef is being deleted
-			return nil
-		}
-		r.Log.Info("Watch a change for Kubeflow resource", "instance", a.GetName(), "namespace", a.GetNamespace())
-		return []reconcile.Request{{NamespacedName: namespacedName}}
-	} else if a.GetObjectKind().GroupVersionKind().Kind == "ConfigMap" {
-		labels := a.GetLabels()
-		if val, ok := labels[deleteConfigMapLabel]; ok {
-			if val == "true" {
-				for k := range kfdefInstances {
-					kfdefCr := strings.Split(k, ".")
-					return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: kfdefCr[0], Namespace: kfdefCr[1]}}}
-				}
-			}
+	instance, found := r.kfdefOwner(a)
+	if !found || instance.GetDeletionTimestamp() != nil {
+		// KfDef CR may have been deleted, or is being deleted
+		return nil
+	}
+	r.Log.Info("Watch a change for Kubeflow resource", "instance", a.GetName(), "namespace", a.GetNamespace())
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}}}
+}
+
+// watchDeleteConfigMap triggers the uninstall of the KfDefs when the delete ConfigMap is created.
+func (r *KfDefReconciler) watchDeleteConfigMap(a client.Object) (requests []reconcile.Request) {
+	for k := range kfdefInstances {
+		if name, namespace, ok := kfutils.ParseKfDefInstanceKey(k); ok {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}}
 		}
 	}
 	return nil
+}
 
+// kfdefOwner returns the KfDef managing the resource, from its ownership labels. The name label
+// doesn't hold the name of KfDefs too long for label values, so the KfDefs of the namespace are
+// matched against it.
+func (r *KfDefReconciler) kfdefOwner(a client.Object) (*kfdefappskubefloworgv1.KfDef, bool) {
+	labels := a.GetLabels()
+	name, ok := labels[kfutils.KfDefNameLabel]
+	if !ok {
+		return nil, false
+	}
+	namespace, ok := labels[kfutils.KfDefNamespaceLabel]
+	if !ok {
+		return nil, false
+	}
+	kfdefs := &kfdefappskubefloworgv1.KfDefList{}
+	if err := r.Client.List(context.TODO(), kfdefs, client.InNamespace(namespace)); err != nil {
+		r.Log.Error(err, "Failed to list KfDefs.", "namespace", namespace)
+		return nil, false
+	}
+	for i := range kfdefs.Items {
+		if kfutils.LabelValue(kfdefs.Items[i].Name) == name {
+			return &kfdefs.Items[i], true
+		}
+	}
+	return nil, false
 }
 
 var kfdefPredicates = predicate.Funcs{
@@ -369,72 +412,83 @@ var kfdefPredicates = predicate.Funcs{
 		return false
 	},
 	UpdateFunc: func(e event.UpdateEvent) bool {
-		return true
+		return !isStatusUpdate(e)
 	},
 }
 
-var ownedResourcePredicates = predicate.Funcs{
-	CreateFunc: func(e event.CreateEvent) bool {
-		// handle create event if object has kind configMap
-		if e.Object.GetObjectKind().GroupVersionKind().Kind == "ConfigMap" {
-			labels := e.Object.GetLabels()
-			if val, ok := labels[deleteConfigMapLabel]; ok {
-				if val == "true" {
-					return true
-				}
-			}
-		}
+// kfdefSpecPredicates ignores the updates of the KfDef status, e.g. the repos cache written
+// after an apply or when the repos are polled, which would otherwise trigger another apply.
+var kfdefSpecPredicates = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !isStatusUpdate(e)
+	},
+}
+
+// isStatusUpdate returns true if only the status of the object changed.
+func isStatusUpdate(e event.UpdateEvent) bool {
+	if e.ObjectOld == nil || e.ObjectNew == nil {
+		return false
+	}
+	return e.ObjectOld.GetGeneration() == e.ObjectNew.GetGeneration() &&
+		reflect.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()) &&
+		reflect.DeepEqual(e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations()) &&
+		reflect.DeepEqual(e.ObjectOld.GetFinalizers(), e.ObjectNew.GetFinalizers()) &&
+		reflect.DeepEqual(e.ObjectOld.GetDeletionTimestamp(), e.ObjectNew.GetDeletionTimestamp())
+}
 
+// managedResourcePredicates handles the changes of the resources managed by a KfDef, selected
+// by their ownership labels.
+var managedResourcePredicates = predicate.Funcs{
+	CreateFunc: func(_ event.CreateEvent) bool {
+		// the resources are created by the operator
 		return false
 	},
 	GenericFunc: func(_ event.GenericEvent) bool {
 		// no action
 		return false
 	},
-	DeleteFunc: func(e event.DeleteEvent) bool {
-		// handle deletion event
-		object, err := meta.Accessor(e.Object)
-		if err != nil {
-			return false
-		}
-		// if this object has an owner, let the owner handle the appropriate recovery
-		if len(object.GetOwnerReferences()) > 0 {
-			return false
-		}
+	DeleteFunc: func(_ event.DeleteEvent) bool {
+		// recreate the deleted resources
 		return true
 	},
 	UpdateFunc: func(e event.UpdateEvent) bool {
-		// handle update events
-		object, err := meta.Accessor(e.ObjectOld)
-		if err != nil {
-			return false
-		}
-		// if this object has an owner, let the owner handle the appropriate recovery
-		if len(object.GetOwnerReferences()) > 0 {
-			return false
-		}
-		// TODO:  Add update log message when plugin is integrated. We need to only log events for the resources with 'configurable' label
-		return true
+		// revert the changes of the spec and of the metadata, but not those of the status
+		return managedResourceChanged.Update(e)
 	},
 }
 
-// kfApply is equivalent of kfctl apply
-func kfApply(instance *kfdefappskubefloworgv1.KfDef) error {
-	kfdefLog.Info("Creating a new KubeFlow Deployment", "KubeFlow.Namespace", instance.Namespace)
-	kfApp, err := kfLoadConfig(instance, "apply")
+var managedResourceChanged = predicate.Or(predicate.GenerationChangedPredicate{},
+	predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{})
+
+// kfApply is equivalent of kfctl apply. Only the given applications are rendered and applied,
+// all of them if nil.
+func kfApply(instance *kfdefappskubefloworgv1.KfDef, applications []string) error {
+	kfdefLog.Info("Creating a new KubeFlow Deployment", "KubeFlow.Namespace", instance.Namespace,
+		"applications", applications)
+	kfApp, err := kfLoadConfig(instance, "apply", applications)
 	if err != nil {
 		kfdefLog.Error(err, "failed to load KfApp")
 		return err
 	}
 	// Apply kfApp.
 	err = kfApp.Apply(kftypesv3.K8S)
-	return err
+	// The platform plugins record whether they succeeded or failed in the config file either way.
+	if statusErr := setPluginConditionsStatus(instance); statusErr != nil {
+		kfdefLog.Error(statusErr, "failed to read the plugin conditions")
+	}
+	if err != nil {
+		return err
+	}
+	if err := setRenderedKindsStatus(instance); err != nil {
+		return err
+	}
+	return setReposCacheStatus(instance)
 }
 
 // kfDelete is equivalent of kfctl delete
 func kfDelete(instance *kfdefappskubefloworgv1.KfDef) error {
 	kfdefLog.Info("Uninstall Kubeflow.", "KubeFlow.Namespace", instance.Namespace)
-	kfApp, err := kfLoadConfig(instance, "delete")
+	kfApp, err := kfLoadConfig(instance, "delete", nil)
 	if err != nil {
 		kfdefLog.Error(err, "Failed to load KfApp")
 		return err
@@ -444,7 +498,7 @@ func kfDelete(instance *kfdefappskubefloworgv1.KfDef) error {
 	return err
 }
 
-func kfLoadConfig(instance *kfdefappskubefloworgv1.KfDef, action string) (kftypesv3.KfApp, error) {
+func kfLoadConfig(instance *kfdefappskubefloworgv1.KfDef, action string, applications []string) (kftypesv3.KfApp, error) {
 	// Define kfApp
 	kfdefBytes, _ := yaml.Marshal(instance)
 
@@ -455,7 +509,7 @@ func kfLoadConfig(instance *kfdefappskubefloworgv1.KfDef, action string) (kftype
 		return nil, err
 	}
 
-	configFilePath := path.Join(kfAppDir, "config.yaml")
+	configFilePath := kfAppConfigPath(instance)
 	err := ioutil.WriteFile(configFilePath, kfdefBytes, 0644)
 	if err != nil {
 		kfdefLog.Error(err, "Failed to write config.yaml")
@@ -468,6 +522,12 @@ func kfLoadConfig(instance *kfdefappskubefloworgv1.KfDef, action string) (kftype
 		setAnnotations(configFilePath, map[string]string{
 			setAnnotationAnn: "true",
 		})
+		if applications != nil {
+			selectedAnn := strings.Join([]string{kfutils.KfDefAnnotation, kfutils.SelectedApplications}, "/")
+			setAnnotations(configFilePath, map[string]string{
+				selectedAnn: strings.Join(applications, ","),
+			})
+		}
 	}
 
 	if action == "delete" {
@@ -493,6 +553,11 @@ func kfLoadConfig(instance *kfdefappskubefloworgv1.KfDef, action string) (kftype
 	return kfApp, nil
 }
 
+// kfAppConfigPath returns the path of the config file the KfDef instance is written to when it is applied.
+func kfAppConfigPath(instance *kfdefappskubefloworgv1.KfDef) string {
+	return path.Join("/tmp", instance.GetNamespace(), instance.GetName(), "config.yaml")
+}
+
 func setAnnotations(configPath string, annotations map[string]string) error {
 	config, err := kfloaders.LoadConfigFromURI(configPath)
 	if err != nil {
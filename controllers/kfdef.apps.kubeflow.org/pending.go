@@ -0,0 +1,160 @@
+package kfdefappskubefloworg
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+)
+
+// pendingApplications records, for each KfDef, the applications to render and apply at its next
+// reconcile. A KfDef is reconciled for only some of its applications when it's only triggered by
+// changes to objects they reference, and its spec didn't change since all of them were applied.
+type pendingApplications struct {
+	mu     sync.Mutex
+	kfdefs map[types.NamespacedName]*pendingKfDef
+}
+
+type pendingKfDef struct {
+	// all is true when all the applications are to be rendered.
+	all          bool
+	applications sets.String
+	// appliedGeneration is the generation of the KfDef when all its applications were last applied.
+	appliedGeneration int64
+}
+
+func newPendingApplications() *pendingApplications {
+	return &pendingApplications{kfdefs: map[types.NamespacedName]*pendingKfDef{}}
+}
+
+func (p *pendingApplications) get(key types.NamespacedName) *pendingKfDef {
+	pending, ok := p.kfdefs[key]
+	if !ok {
+		// Nothing was applied yet, so all the applications are rendered.
+		pending = &pendingKfDef{all: true, applications: sets.NewString(), appliedGeneration: -1}
+		p.kfdefs[key] = pending
+	}
+	return pending
+}
+
+// add records the applications to render at the next reconcile of the KfDef, all of them if nil.
+func (p *pendingApplications) add(key types.NamespacedName, applications []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pending := p.get(key)
+	if applications == nil {
+		pending.all = true
+		return
+	}
+	pending.applications.Insert(applications...)
+}
+
+// take returns the applications to render for the KfDef at the given generation, or nil for all of
+// them, and forgets them.
+func (p *pendingApplications) take(key types.NamespacedName, generation int64) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pending := p.get(key)
+	all := pending.all || pending.applications.Len() == 0 || pending.appliedGeneration != generation
+	applications := pending.applications.List()
+	pending.all = false
+	pending.applications = sets.NewString()
+	if all {
+		return nil
+	}
+	return applications
+}
+
+// applied records that the applications taken for the KfDef at the given generation were applied,
+// or, on failure, that they are still to be rendered.
+func (p *pendingApplications) applied(key types.NamespacedName, generation int64, applications []string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pending := p.get(key)
+	if err != nil {
+		if applications == nil {
+			pending.all = true
+		}
+		pending.applications.Insert(applications...)
+		return
+	}
+	if applications == nil {
+		pending.appliedGeneration = generation
+	}
+}
+
+// forget drops what is recorded for a deleted KfDef.
+func (p *pendingApplications) forget(key types.NamespacedName) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.kfdefs, key)
+}
+
+// renderAll wraps a handler so the KfDefs it enqueues render all their applications.
+func (p *pendingApplications) renderAll(h handler.EventHandler) handler.EventHandler {
+	return &renderAllHandler{handler: h, pending: p}
+}
+
+type renderAllHandler struct {
+	handler handler.EventHandler
+	pending *pendingApplications
+}
+
+// InjectFunc implements inject.Injector so the wrapped handler is injected the dependencies it
+// needs, e.g. the scheme and the REST mapper of EnqueueRequestForOwner.
+func (h *renderAllHandler) InjectFunc(f inject.Func) error {
+	return f(h.handler)
+}
+
+func (h *renderAllHandler) queue(q workqueue.RateLimitingInterface) workqueue.RateLimitingInterface {
+	return &renderAllQueue{RateLimitingInterface: q, pending: h.pending}
+}
+
+func (h *renderAllHandler) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Create(e, h.queue(q))
+}
+
+func (h *renderAllHandler) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Update(e, h.queue(q))
+}
+
+func (h *renderAllHandler) Delete(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Delete(e, h.queue(q))
+}
+
+func (h *renderAllHandler) Generic(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+	h.handler.Generic(e, h.queue(q))
+}
+
+// renderAllQueue records the KfDefs added to the queue as rendering all their applications.
+type renderAllQueue struct {
+	workqueue.RateLimitingInterface
+	pending *pendingApplications
+}
+
+func (q *renderAllQueue) record(item interface{}) {
+	if request, ok := item.(reconcile.Request); ok {
+		q.pending.add(request.NamespacedName, nil)
+	}
+}
+
+func (q *renderAllQueue) Add(item interface{}) {
+	q.record(item)
+	q.RateLimitingInterface.Add(item)
+}
+
+func (q *renderAllQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.record(item)
+	q.RateLimitingInterface.AddAfter(item, duration)
+}
+
+func (q *renderAllQueue) AddRateLimited(item interface{}) {
+	q.record(item)
+	q.RateLimitingInterface.AddRateLimited(item)
+}
@@ -0,0 +1,88 @@
+package kfdefappskubefloworg
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+)
+
+func TestPendingApplications(t *testing.T) {
+	key := types.NamespacedName{Name: "opendatahub", Namespace: "opendatahub"}
+	p := newPendingApplications()
+
+	type step struct {
+		name string
+		do   func()
+		// generation the applications are taken at
+		generation int64
+		expected   []string
+	}
+
+	steps := []step{
+		{
+			name:       "first reconcile",
+			do:         func() { p.add(key, []string{"dashboard"}) },
+			generation: 1,
+			expected:   nil,
+		},
+		{
+			name: "reference changed",
+			do: func() {
+				p.applied(key, 1, nil, nil)
+				p.add(key, []string{"dashboard"})
+			},
+			generation: 1,
+			expected:   []string{"dashboard"},
+		},
+		{
+			name: "failed apply",
+			do: func() {
+				p.applied(key, 1, []string{"dashboard"}, fmt.Errorf("failed"))
+				p.add(key, []string{"notebooks"})
+			},
+			generation: 1,
+			expected:   []string{"dashboard", "notebooks"},
+		},
+		{
+			name: "spec changed",
+			do: func() {
+				p.applied(key, 1, []string{"dashboard", "notebooks"}, nil)
+				p.add(key, []string{"dashboard"})
+			},
+			generation: 2,
+			expected:   nil,
+		},
+		{
+			name: "other change",
+			do: func() {
+				p.applied(key, 2, nil, nil)
+				p.add(key, []string{"dashboard"})
+				p.renderAll(&handler.EnqueueRequestForObject{}).Generic(event.GenericEvent{
+					Object: &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "opendatahub", Namespace: "opendatahub"}},
+				}, workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()))
+			},
+			generation: 2,
+			expected:   nil,
+		},
+		{
+			name:       "nothing changed",
+			do:         func() { p.applied(key, 2, nil, nil) },
+			generation: 2,
+			expected:   nil,
+		},
+	}
+
+	for _, s := range steps {
+		s.do()
+		if actual := p.take(key, s.generation); !reflect.DeepEqual(actual, s.expected) {
+			t.Errorf("%v: got %v; want %v", s.name, actual, s.expected)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package kfdefappskubefloworg
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	kfdefv1 "github.com/opendatahub-io/opendatahub-operator/apis/kfdef.apps.kubeflow.org/v1"
+	kfloaders "github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig/loaders"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// repoPollPeriod is how often the poller looks for repos due to be checked.
+const repoPollPeriod = 30 * time.Second
+
+// repoPoller checks the sources of the repos setting a PollInterval and triggers a reconcile
+// of the KfDef when the revision of one of them differs from the one it was applied at.
+type repoPoller struct {
+	client client.Client
+	log    logr.Logger
+	// events receives the KfDefs to reconcile.
+	events chan event.GenericEvent
+	// resolve returns the current revision of a repo of the KfDef, given its cache as of the last check.
+	resolve func(instance *kfdefv1.KfDef, repo kfdefv1.Repo, cache kfdefv1.RepoCache) (string, error)
+}
+
+func newRepoPoller(c client.Client, log logr.Logger) *repoPoller {
+	return &repoPoller{
+		client:  c,
+		log:     log,
+		events:  make(chan event.GenericEvent),
+		resolve: resolveRepoRevision,
+	}
+}
+
+// Start implements manager.Runnable.
+func (p *repoPoller) Start(ctx context.Context) error {
+	ticker := time.NewTicker(repoPollPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			p.poll(ctx, time.Now())
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable so only the leader polls.
+func (p *repoPoller) NeedLeaderElection() bool {
+	return true
+}
+
+// poll checks the repos which are due and records when they were checked in the status.
+func (p *repoPoller) poll(ctx context.Context, now time.Time) {
+	kfdefs := &kfdefv1.KfDefList{}
+	if err := p.client.List(ctx, kfdefs); err != nil {
+		p.log.Error(err, "Failed to list KfDef CRs.")
+		return
+	}
+	for i := range kfdefs.Items {
+		instance := &kfdefs.Items[i]
+		if instance.GetDeletionTimestamp() != nil {
+			continue
+		}
+		checked, changed := p.check(instance, now)
+		if !checked {
+			continue
+		}
+		if err := p.client.Status().Update(ctx, instance); err != nil {
+			p.log.Error(err, "Failed to update the repos cache status", "instance", instance.Name)
+		}
+		if changed {
+			select {
+			case p.events <- event.GenericEvent{Object: instance}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// check resolves the revision of the repos of the KfDef which are due and sets their LastCheckedTime.
+// The repos are checked concurrently so a slow source doesn't hold up the others.
+// It returns whether any repo was checked and whether any revision changed.
+func (p *repoPoller) check(instance *kfdefv1.KfDef, now time.Time) (checked bool, changed bool) {
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, repo := range instance.Spec.Repos {
+		if repo.PollInterval == nil || repo.PollInterval.Duration <= 0 {
+			continue
+		}
+		// Repos are only polled once they have been fetched by an apply.
+		cache := findRepoCache(instance, repo.Name)
+		if cache == nil {
+			continue
+		}
+		if cache.LastCheckedTime != nil && now.Before(cache.LastCheckedTime.Add(repo.PollInterval.Duration)) {
+			continue
+		}
+		checked = true
+		previous := *cache
+		lastChecked := metav1.NewTime(now)
+		cache.LastCheckedTime = &lastChecked
+
+		wg.Add(1)
+		go func(repo kfdefv1.Repo) {
+			defer wg.Done()
+			revision, err := p.resolve(instance, repo, previous)
+			if err != nil {
+				p.log.Error(err, "Failed to check repo for changes", "instance", instance.Name, "repo", repo.Name)
+				return
+			}
+			if revision != previous.ResolvedRevision {
+				p.log.Info("Repo source changed", "instance", instance.Name, "repo", repo.Name,
+					"revision", revision, "previous", previous.ResolvedRevision)
+				mu.Lock()
+				changed = true
+				mu.Unlock()
+			}
+		}(repo)
+	}
+	wg.Wait()
+	return checked, changed
+}
+
+func findRepoCache(instance *kfdefv1.KfDef, name string) *kfdefv1.RepoCache {
+	for i := range instance.Status.ReposCache {
+		if instance.Status.ReposCache[i].Name == name {
+			return &instance.Status.ReposCache[i]
+		}
+	}
+	return nil
+}
+
+// resolveRepoRevision returns the current revision of the repo source. Sources which can only tell
+// whether they changed since the last check aren't downloaded again if they didn't.
+func resolveRepoRevision(instance *kfdefv1.KfDef, repo kfdefv1.Repo, cache kfdefv1.RepoCache) (string, error) {
+	config, err := kfloaders.V1{}.LoadKfConfig(instance)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range config.Spec.Repos {
+		if r.Name == repo.Name {
+			if cache.LastCheckedTime == nil {
+				return config.ResolveRepoRevision(r)
+			}
+			return config.ResolveRepoRevisionSince(r, cache.ResolvedRevision, cache.LastCheckedTime.Time)
+		}
+	}
+	return "", fmt.Errorf("repo %v not found", repo.Name)
+}
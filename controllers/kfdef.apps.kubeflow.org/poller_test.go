@@ -0,0 +1,109 @@
+package kfdefappskubefloworg
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	kfdefv1 "github.com/opendatahub-io/opendatahub-operator/apis/kfdef.apps.kubeflow.org/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestRepoPoller(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := kfdefv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add KfDef to scheme; %v", err)
+	}
+
+	now := time.Now()
+	checked := metav1.NewTime(now.Add(-time.Minute))
+	interval := &metav1.Duration{Duration: 5 * time.Minute}
+
+	newKfDef := func(name string, repo kfdefv1.Repo, cache kfdefv1.RepoCache) *kfdefv1.KfDef {
+		return &kfdefv1.KfDef{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "opendatahub"},
+			Spec:       kfdefv1.KfDefSpec{Repos: []kfdefv1.Repo{repo}},
+			Status:     kfdefv1.KfDefStatus{ReposCache: []kfdefv1.RepoCache{cache}},
+		}
+	}
+
+	kfdefs := []runtime.Object{
+		newKfDef("changed",
+			kfdefv1.Repo{Name: "manifests", URI: "https://example.com/changed.tar.gz", PollInterval: interval},
+			kfdefv1.RepoCache{Name: "manifests", ResolvedRevision: "v1"}),
+		newKfDef("unchanged",
+			kfdefv1.Repo{Name: "manifests", URI: "https://example.com/unchanged.tar.gz", PollInterval: interval},
+			kfdefv1.RepoCache{Name: "manifests", ResolvedRevision: "v2"}),
+		newKfDef("not-due",
+			kfdefv1.Repo{Name: "manifests", URI: "https://example.com/changed.tar.gz", PollInterval: interval},
+			kfdefv1.RepoCache{Name: "manifests", ResolvedRevision: "v1", LastCheckedTime: &checked}),
+		newKfDef("not-polled",
+			kfdefv1.Repo{Name: "manifests", URI: "https://example.com/changed.tar.gz"},
+			kfdefv1.RepoCache{Name: "manifests", ResolvedRevision: "v1"}),
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(kfdefs...).Build()
+	p := newRepoPoller(c, logr.Discard())
+	var mu sync.Mutex
+	resolved := map[string]bool{}
+	p.resolve = func(instance *kfdefv1.KfDef, repo kfdefv1.Repo, cache kfdefv1.RepoCache) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		resolved[instance.Name] = true
+		return "v2", nil
+	}
+
+	var events []event.GenericEvent
+	done := make(chan struct{})
+	go func() {
+		for e := range p.events {
+			events = append(events, e)
+		}
+		close(done)
+	}()
+	p.poll(context.TODO(), now)
+	close(p.events)
+	<-done
+
+	if len(events) != 1 || events[0].Object.GetName() != "changed" {
+		t.Fatalf("expected a single event for the changed KfDef; got %v", events)
+	}
+
+	type testCase struct {
+		name            string
+		expectResolved  bool
+		expectedChecked *metav1.Time
+	}
+
+	testCases := []testCase{
+		{name: "changed", expectResolved: true, expectedChecked: &metav1.Time{Time: now}},
+		{name: "unchanged", expectResolved: true, expectedChecked: &metav1.Time{Time: now}},
+		{name: "not-due", expectResolved: false, expectedChecked: &checked},
+		{name: "not-polled", expectResolved: false, expectedChecked: nil},
+	}
+
+	for _, tc := range testCases {
+		if resolved[tc.name] != tc.expectResolved {
+			t.Errorf("%v: resolved; got %v; want %v", tc.name, resolved[tc.name], tc.expectResolved)
+		}
+		instance := &kfdefv1.KfDef{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Name: tc.name, Namespace: "opendatahub"}, instance); err != nil {
+			t.Fatalf("%v: failed to get KfDef; %v", tc.name, err)
+		}
+		actual := instance.Status.ReposCache[0].LastCheckedTime
+		if (actual == nil) != (tc.expectedChecked == nil) ||
+			(actual != nil && actual.Unix() != tc.expectedChecked.Unix()) {
+			t.Errorf("%v: LastCheckedTime; got %v; want %v", tc.name, actual, tc.expectedChecked)
+		}
+		// The revision is only updated once the KfDef is applied again.
+		if instance.Status.ReposCache[0].ResolvedRevision == "" {
+			t.Errorf("%v: ResolvedRevision was cleared", tc.name)
+		}
+	}
+}
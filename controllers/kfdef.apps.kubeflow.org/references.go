@@ -0,0 +1,186 @@
+package kfdefappskubefloworg
+
+import (
+	"context"
+	"strings"
+
+	kfdefv1 "github.com/opendatahub-io/opendatahub-operator/apis/kfdef.apps.kubeflow.org/v1"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// referencedObjectsIndex is the field index of the KfDefs by the ConfigMaps and Secrets they reference.
+const referencedObjectsIndex = "kfdef.referencedObjects"
+
+// objectReference is a ConfigMap or Secret a KfDef reads when it is applied, along with the
+// application reading it, which is empty if it's read for all of them.
+type objectReference struct {
+	kind        string
+	namespace   string
+	name        string
+	application string
+}
+
+// objectKey returns the key of a ConfigMap or Secret in the referenced objects index.
+func objectKey(kind string, namespace string, name string) string {
+	return strings.Join([]string{kind, namespace, name}, "/")
+}
+
+// kfdefReferences returns the ConfigMaps and Secrets the KfDef reads when it is applied, e.g. as
+// the source of a repo, for the credentials used to fetch a repo, for Helm values or as the source
+// of a secret, an application parameter or an image pull secret copied. Only the objects of the
+// namespace of the KfDef are read.
+func kfdefReferences(instance *kfdefv1.KfDef) []objectReference {
+	var refs []objectReference
+	add := func(kind string, namespace string, name string, application string) {
+		if namespace == "" {
+			namespace = instance.Namespace
+		}
+		refs = append(refs, objectReference{kind: kind, namespace: namespace, name: name, application: application})
+	}
+	addKeyRef := func(kind string, ref *kfdefv1.ObjectKeyRef, application string) {
+		if ref != nil && (ref.Namespace == "" || ref.Namespace == instance.Namespace) {
+			add(kind, ref.Namespace, ref.Name, application)
+		}
+	}
+
+	// The repos are shared by the applications, so a change to their sources renders all of them.
+	for _, repo := range instance.Spec.Repos {
+		if kfconfig.IsObjectURI(repo.URI) {
+			kind, namespace, name, err := kfconfig.ParseObjectURI(repo.URI, instance.Namespace)
+			if err == nil && namespace == instance.Namespace {
+				add(kind, namespace, name, "")
+			}
+		}
+		if repo.SecretRef != nil {
+			add(kfconfig.SecretKind, instance.Namespace, repo.SecretRef.Name, "")
+		}
+	}
+	for _, app := range instance.Spec.Applications {
+		if app.HelmConfig != nil {
+			for _, ref := range app.HelmConfig.ValuesFrom {
+				add(ref.Kind, instance.Namespace, ref.Name, app.Name)
+			}
+		}
+		var params []kfdefv1.NameValue
+		if app.KustomizeConfig != nil {
+			params = append(params, app.KustomizeConfig.Parameters...)
+		}
+		if app.ManifestsConfig != nil {
+			params = append(params, app.ManifestsConfig.Parameters...)
+		}
+		for _, p := range params {
+			if p.ValueFrom != nil {
+				addKeyRef(kfconfig.ConfigMapKind, p.ValueFrom.ConfigMapKeyRef, app.Name)
+				addKeyRef(kfconfig.SecretKind, p.ValueFrom.SecretKeyRef, app.Name)
+			}
+		}
+		for _, secret := range app.ImagePullSecrets {
+			if secret.CopyFromNamespace == instance.Namespace {
+				add(kfconfig.SecretKind, secret.CopyFromNamespace, secret.Name, app.Name)
+			}
+		}
+	}
+	for _, secret := range instance.Spec.ImagePullSecrets {
+		if secret.CopyFromNamespace == instance.Namespace {
+			add(kfconfig.SecretKind, secret.CopyFromNamespace, secret.Name, "")
+		}
+	}
+	// The secrets of the KfDef can be used by any application or platform.
+	for _, secret := range instance.Spec.Secrets {
+		if secret.SecretSource != nil {
+			addKeyRef(kfconfig.ConfigMapKind, secret.SecretSource.ConfigMapKeyRef, "")
+			addKeyRef(kfconfig.SecretKind, secret.SecretSource.SecretKeyRef, "")
+		}
+	}
+	return refs
+}
+
+// referencedObjectKeys indexes the KfDef by the keys of the objects it references.
+func referencedObjectKeys(obj client.Object) []string {
+	instance, ok := obj.(*kfdefv1.KfDef)
+	if !ok {
+		return nil
+	}
+	keys := sets.NewString()
+	for _, ref := range kfdefReferences(instance) {
+		keys.Insert(objectKey(ref.kind, ref.namespace, ref.name))
+	}
+	return keys.List()
+}
+
+// referencingApplications returns the applications of the KfDef reading the ConfigMap or Secret,
+// all of them being returned as nil, and whether the KfDef reads it at all.
+func referencingApplications(instance *kfdefv1.KfDef, kind string, namespace string, name string) ([]string, bool) {
+	apps := sets.NewString()
+	referenced := false
+	for _, ref := range kfdefReferences(instance) {
+		if ref.kind != kind || ref.namespace != namespace || ref.name != name {
+			continue
+		}
+		if ref.application == "" {
+			return nil, true
+		}
+		referenced = true
+		apps.Insert(ref.application)
+	}
+	return apps.List(), referenced
+}
+
+// watchReferencedObjects maps a change to a ConfigMap or Secret to the KfDefs referencing it, and
+// records which of their applications read it so only those are rendered and applied again.
+func (r *KfDefReconciler) watchReferencedObjects(a client.Object) (requests []reconcile.Request) {
+	var kind string
+	switch a.(type) {
+	case *v1.ConfigMap:
+		kind = kfconfig.ConfigMapKind
+	case *v1.Secret:
+		kind = kfconfig.SecretKind
+	default:
+		return nil
+	}
+
+	kfdefs := &kfdefv1.KfDefList{}
+	if err := r.Client.List(context.TODO(), kfdefs,
+		client.MatchingFields{referencedObjectsIndex: objectKey(kind, a.GetNamespace(), a.GetName())}); err != nil {
+		r.Log.Error(err, "Failed to list KfDef CRs.")
+		return nil
+	}
+	for i := range kfdefs.Items {
+		instance := &kfdefs.Items[i]
+		if instance.GetDeletionTimestamp() != nil {
+			continue
+		}
+		apps, referenced := referencingApplications(instance, kind, a.GetNamespace(), a.GetName())
+		if !referenced {
+			continue
+		}
+		r.Log.Info("Watch a change for a resource referenced by KfDef", "kind", kind,
+			"name", a.GetName(), "namespace", a.GetNamespace(), "instance", instance.Name, "applications", apps)
+		namespacedName := types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}
+		r.pending.add(namespacedName, apps)
+		requests = append(requests, reconcile.Request{NamespacedName: namespacedName})
+	}
+	return requests
+}
+
+var referencedObjectPredicates = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return true
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return true
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return true
+	},
+}
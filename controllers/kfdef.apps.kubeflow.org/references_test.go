@@ -0,0 +1,242 @@
+package kfdefappskubefloworg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-logr/logr"
+	kfdefv1 "github.com/opendatahub-io/opendatahub-operator/apis/kfdef.apps.kubeflow.org/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestWatchReferencedObjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := kfdefv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add KfDef to scheme; %v", err)
+	}
+
+	kfdefs := []runtime.Object{
+		&kfdefv1.KfDef{
+			ObjectMeta: metav1.ObjectMeta{Name: "configmap-repo", Namespace: "opendatahub"},
+			Spec: kfdefv1.KfDefSpec{
+				Repos: []kfdefv1.Repo{{Name: "manifests", URI: "configmap://manifests"}},
+			},
+		},
+		&kfdefv1.KfDef{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-repo", Namespace: "other"},
+			Spec: kfdefv1.KfDefSpec{
+				Repos: []kfdefv1.Repo{{Name: "manifests", URI: "secret://opendatahub/manifests"}},
+			},
+		},
+		&kfdefv1.KfDef{
+			ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "opendatahub"},
+			Spec: kfdefv1.KfDefSpec{
+				Repos: []kfdefv1.Repo{{
+					Name:      "manifests",
+					URI:       "https://example.com/manifests.tar.gz",
+					SecretRef: &kfdefv1.SecretRef{Name: "manifests"},
+				}},
+			},
+		},
+		&kfdefv1.KfDef{
+			ObjectMeta: metav1.ObjectMeta{Name: "helm-values", Namespace: "opendatahub"},
+			Spec: kfdefv1.KfDefSpec{
+				Applications: []kfdefv1.Application{{
+					Name: "vendor",
+					HelmConfig: &kfdefv1.HelmConfig{
+						Chart:      "oci://registry.example.com/charts/vendor",
+						ValuesFrom: []kfdefv1.ValuesReference{{Kind: "ConfigMap", Name: "vendor-values"}},
+					},
+				}},
+			},
+		},
+		&kfdefv1.KfDef{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-sources", Namespace: "opendatahub"},
+			Spec: kfdefv1.KfDefSpec{
+				Secrets: []kfdefv1.Secret{
+					{
+						Name: "password",
+						SecretSource: &kfdefv1.SecretSource{
+							SecretKeyRef: &kfdefv1.ObjectKeyRef{Name: "credentials", Key: "password"},
+						},
+					},
+					{
+						Name: "domain",
+						SecretSource: &kfdefv1.SecretSource{
+							ConfigMapKeyRef: &kfdefv1.ObjectKeyRef{Namespace: "shared", Name: "settings", Key: "domain"},
+						},
+					},
+				},
+			},
+		},
+		&kfdefv1.KfDef{
+			ObjectMeta: metav1.ObjectMeta{Name: "parameters", Namespace: "opendatahub"},
+			Spec: kfdefv1.KfDefSpec{
+				Applications: []kfdefv1.Application{{
+					Name: "dashboard",
+					KustomizeConfig: &kfdefv1.KustomizeConfig{
+						Parameters: []kfdefv1.NameValue{{
+							Name: "oauth-secret",
+							ValueFrom: &kfdefv1.ValueSource{
+								SecretKeyRef: &kfdefv1.ObjectKeyRef{Name: "dashboard-oauth", Key: "secret"},
+							},
+						}},
+					},
+				}},
+			},
+		},
+		&kfdefv1.KfDef{
+			ObjectMeta: metav1.ObjectMeta{Name: "pull-secrets", Namespace: "opendatahub"},
+			Spec: kfdefv1.KfDefSpec{
+				Applications: []kfdefv1.Application{{
+					Name:             "dashboard",
+					ImagePullSecrets: []kfdefv1.ImagePullSecret{{Name: "registry", CopyFromNamespace: "opendatahub"}},
+				}},
+			},
+		},
+	}
+
+	// The fake client doesn't filter on the referenced objects index, so every KfDef is listed.
+	r := &KfDefReconciler{
+		Client:  fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(kfdefs...).Build(),
+		Log:     logr.Discard(),
+		pending: newPendingApplications(),
+	}
+
+	request := func(name string, namespace string) reconcile.Request {
+		return reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	}
+
+	type testCase struct {
+		object   client.Object
+		expected []reconcile.Request
+	}
+
+	testCases := []testCase{
+		{
+			object:   &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: "opendatahub"}},
+			expected: []reconcile.Request{request("configmap-repo", "opendatahub")},
+		},
+		{
+			object:   &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: "opendatahub"}},
+			expected: []reconcile.Request{request("credentials", "opendatahub")},
+		},
+		{
+			object:   &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "vendor-values", Namespace: "opendatahub"}},
+			expected: []reconcile.Request{request("helm-values", "opendatahub")},
+		},
+		{
+			object:   &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "vendor-values", Namespace: "opendatahub"}},
+			expected: nil,
+		},
+		{
+			object:   &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: "other"}},
+			expected: nil,
+		},
+		{
+			object:   &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "opendatahub"}},
+			expected: []reconcile.Request{request("secret-sources", "opendatahub")},
+		},
+		{
+			object:   &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "shared"}},
+			expected: nil,
+		},
+		{
+			object:   &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "shared"}},
+			expected: nil,
+		},
+		{
+			object:   &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "dashboard-oauth", Namespace: "opendatahub"}},
+			expected: []reconcile.Request{request("parameters", "opendatahub")},
+		},
+		{
+			object:   &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "dashboard-oauth", Namespace: "opendatahub"}},
+			expected: nil,
+		},
+		{
+			object:   &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "opendatahub"}},
+			expected: []reconcile.Request{request("pull-secrets", "opendatahub")},
+		},
+		{
+			object:   &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "shared"}},
+			expected: nil,
+		},
+	}
+
+	for _, c := range testCases {
+		actual := r.watchReferencedObjects(c.object)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("watchReferencedObjects(%T); got %v; want %v", c.object, actual, c.expected)
+		}
+	}
+}
+
+func TestWatchReferencedObjects_Applications(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := kfdefv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add KfDef to scheme; %v", err)
+	}
+	newApp := func(name string, secret string) kfdefv1.Application {
+		return kfdefv1.Application{
+			Name: name,
+			KustomizeConfig: &kfdefv1.KustomizeConfig{
+				Parameters: []kfdefv1.NameValue{{
+					Name: "secret",
+					ValueFrom: &kfdefv1.ValueSource{
+						SecretKeyRef: &kfdefv1.ObjectKeyRef{Name: secret, Key: "secret"},
+					},
+				}},
+			},
+		}
+	}
+	instance := &kfdefv1.KfDef{
+		ObjectMeta: metav1.ObjectMeta{Name: "opendatahub", Namespace: "opendatahub", Generation: 2},
+		Spec: kfdefv1.KfDefSpec{
+			Repos: []kfdefv1.Repo{{Name: "manifests", URI: "secret://manifests"}},
+			Applications: []kfdefv1.Application{
+				newApp("dashboard", "dashboard-oauth"),
+				newApp("notebooks", "notebooks-oauth"),
+				newApp("workbenches", "notebooks-oauth"),
+			},
+		},
+	}
+	r := &KfDefReconciler{
+		Client:  fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(instance).Build(),
+		Log:     logr.Discard(),
+		pending: newPendingApplications(),
+	}
+	// All the applications were applied at the current generation.
+	key := types.NamespacedName{Name: "opendatahub", Namespace: "opendatahub"}
+	r.pending.applied(key, 2, r.pending.take(key, 2), nil)
+
+	secret := func(name string) *v1.Secret {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "opendatahub"}}
+	}
+
+	r.watchReferencedObjects(secret("notebooks-oauth"))
+	if actual, expected := r.pending.take(key, 2), []string{"notebooks", "workbenches"}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("applications; got %v; want %v", actual, expected)
+	}
+
+	// The repos are shared by all the applications.
+	r.watchReferencedObjects(secret("dashboard-oauth"))
+	r.watchReferencedObjects(secret("manifests"))
+	if actual := r.pending.take(key, 2); actual != nil {
+		t.Errorf("expected all the applications to be rendered; got %v", actual)
+	}
+
+	expectedKeys := []string{
+		"Secret/opendatahub/dashboard-oauth",
+		"Secret/opendatahub/manifests",
+		"Secret/opendatahub/notebooks-oauth",
+	}
+	if actual := referencedObjectKeys(instance); !reflect.DeepEqual(actual, expectedKeys) {
+		t.Errorf("referencedObjectKeys; got %v; want %v", actual, expectedKeys)
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"reflect"
 
 	kfdefv1 "github.com/opendatahub-io/opendatahub-operator/apis/kfdef.apps.kubeflow.org/v1"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	kfloaders "github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig/loaders"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -56,7 +59,97 @@ func getReconcileStatus(cr *kfdefv1.KfDef, err error) error {
 		Type:           kfdefv1.KfAvailable,
 	})
 
+	// The conditions of the platform plugins are set by the apply, see setPluginConditionsStatus.
+	for _, cond := range cr.Status.Conditions {
+		if isPluginCondition(cond.Type) {
+			conditions = append(conditions, cond)
+		}
+	}
+
 	cr.Status.Conditions = conditions
 
 	return err
 }
+
+// setReposCacheStatus copies the repo caches, including the revisions the repos were fetched at,
+// from the config file written back by the apply into the status of the KfDef.
+// The repos were fetched by the apply, so they are all marked as checked now.
+func setReposCacheStatus(cr *kfdefv1.KfDef) error {
+	config, err := kfloaders.LoadConfigFromURI(kfAppConfigPath(cr))
+	if err != nil {
+		return err
+	}
+	now := metav1.Now()
+	var reposCache []kfdefv1.RepoCache
+	for _, cache := range config.Status.Caches {
+		reposCache = append(reposCache, kfdefv1.RepoCache{
+			Name:             cache.Name,
+			LocalPath:        cache.LocalPath,
+			ResolvedRevision: cache.ResolvedRevision,
+			LastCheckedTime:  &now,
+		})
+	}
+	cr.Status.ReposCache = reposCache
+	return nil
+}
+
+// setRenderedKindsStatus copies the kinds of the resources rendered for the applications from the
+// config file written back by the apply into the status of the KfDef.
+func setRenderedKindsStatus(cr *kfdefv1.KfDef) error {
+	config, err := kfloaders.LoadConfigFromURI(kfAppConfigPath(cr))
+	if err != nil {
+		return err
+	}
+	cr.Status.RenderedKinds = config.Status.RenderedKinds
+	return nil
+}
+
+// setPluginConditionsStatus copies the Succeeded and Failed conditions the platform plugins were
+// given by the apply, as well as the conditions set when the applications were rendered, e.g.
+// UnknownParameters and RenderWarnings, from the config file written back by the apply into the
+// status of the KfDef.
+// It's called whether the apply succeeded or not, so failed plugins show up in the status.
+func setPluginConditionsStatus(cr *kfdefv1.KfDef) error {
+	config, err := kfloaders.LoadConfigFromURI(kfAppConfigPath(cr))
+	if err != nil {
+		return err
+	}
+	conditions := []kfdefv1.KfDefCondition{}
+	for _, cond := range cr.Status.Conditions {
+		if !isPluginCondition(cond.Type) {
+			conditions = append(conditions, cond)
+		}
+	}
+	condTypes := []kfconfig.ConditionType{kfconfig.UnknownParameters, kfconfig.RenderWarnings, kfconfig.PodSecurityHardened}
+	for _, plugin := range config.Spec.Plugins {
+		condTypes = append(condTypes,
+			kfconfig.GetPluginSucceededCondition(plugin.Kind),
+			kfconfig.GetPluginFailedCondition(plugin.Kind))
+	}
+	for _, condType := range condTypes {
+		cond, err := config.GetCondition(condType)
+		if err != nil {
+			continue
+		}
+		conditions = append(conditions, kfdefv1.KfDefCondition{
+			Type:               kfdefv1.KfDefConditionType(cond.Type),
+			Status:             cond.Status,
+			LastUpdateTime:     cond.LastUpdateTime,
+			LastTransitionTime: cond.LastTransitionTime,
+			Reason:             cond.Reason,
+			Message:            cond.Message,
+		})
+	}
+	cr.Status.Conditions = conditions
+	return nil
+}
+
+// isPluginCondition returns true for the conditions set by the apply, e.g. for the platform plugins,
+// as opposed to the conditions of the KfDef itself.
+func isPluginCondition(condType kfdefv1.KfDefConditionType) bool {
+	switch condType {
+	case kfdefv1.KfAvailable, kfdefv1.KfDegraded, kfdefv1.Pending:
+		return false
+	}
+	return true
+}
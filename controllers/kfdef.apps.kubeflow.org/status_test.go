@@ -0,0 +1,77 @@
+package kfdefappskubefloworg
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	kfdefv1 "github.com/opendatahub-io/opendatahub-operator/apis/kfdef.apps.kubeflow.org/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPluginConditionsStatus(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	instance := &kfdefv1.KfDef{
+		ObjectMeta: metav1.ObjectMeta{Name: "opendatahub", Namespace: path.Base(testDir)},
+		Status: kfdefv1.KfDefStatus{
+			Conditions: []kfdefv1.KfDefCondition{
+				{Type: kfdefv1.KfAvailable, Status: corev1.ConditionTrue},
+				{Type: "KfAwsPluginSucceeded", Status: corev1.ConditionTrue},
+			},
+		},
+	}
+
+	// The config file as written back by an apply where the GCP plugin failed
+	// and an application set an unknown parameter.
+	config := &kfdefv1.KfDef{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "kfdef.apps.kubeflow.org/v1", Kind: "KfDef"},
+		ObjectMeta: instance.ObjectMeta,
+		Spec: kfdefv1.KfDefSpec{
+			Plugins: []kfdefv1.Plugin{{TypeMeta: metav1.TypeMeta{Kind: "KfGcpPlugin"}}},
+		},
+		Status: kfdefv1.KfDefStatus{
+			Conditions: []kfdefv1.KfDefCondition{
+				{Type: "KfGcpPluginSucceeded", Status: corev1.ConditionFalse},
+				{Type: "KfGcpPluginFailed", Status: corev1.ConditionTrue, Message: "couldn't set up workload identity"},
+				{Type: "UnknownParameters", Status: corev1.ConditionTrue, Message: "application dashboard sets parameters replica"},
+			},
+		},
+	}
+	configBytes, err := yaml.Marshal(config)
+	if err != nil {
+		t.Fatalf("could not marshal the config; %v", err)
+	}
+	// The config file is read from the app directory of the instance under /tmp, which may be outside testDir.
+	appDir := path.Dir(kfAppConfigPath(instance))
+	t.Cleanup(func() { os.RemoveAll(path.Dir(appDir)) })
+	os.MkdirAll(appDir, os.ModePerm)
+	if err := ioutil.WriteFile(kfAppConfigPath(instance), configBytes, 0644); err != nil {
+		t.Fatalf("could not write the config; %v", err)
+	}
+
+	if err := setPluginConditionsStatus(instance); err != nil {
+		t.Fatalf("could not set the plugin conditions; %v", err)
+	}
+	getReconcileStatus(instance, errors.New("coordinator Apply failed for gcp"))
+
+	var actual []kfdefv1.KfDefConditionType
+	for _, cond := range instance.Status.Conditions {
+		actual = append(actual, cond.Type)
+		if cond.Type == "KfGcpPluginFailed" && cond.Message != "couldn't set up workload identity" {
+			t.Errorf("KfGcpPluginFailed message; got %v", cond.Message)
+		}
+	}
+	// The AWS plugin isn't in the config anymore, so its condition is dropped.
+	expected := []kfdefv1.KfDefConditionType{kfdefv1.KfDegraded, kfdefv1.KfAvailable,
+		"UnknownParameters", "KfGcpPluginSucceeded", "KfGcpPluginFailed"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("conditions; got %v; want %v", actual, expected)
+	}
+}
@@ -0,0 +1,246 @@
+package kfdefappskubefloworg
+
+import (
+	"context"
+	"sync"
+
+	kfutils "github.com/opendatahub-io/opendatahub-operator/pkg/utils"
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	ocbuildv1 "github.com/openshift/api/build/v1"
+	ocimgv1 "github.com/openshift/api/image/v1"
+	admv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	errutil "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
+	"k8s.io/client-go/tools/cache"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// namespacedResourceTypes are the kinds of namespaced resources managed by the operator which are
+// always watched.
+func namespacedResourceTypes() []client.Object {
+	return []client.Object{
+		&appsv1.Deployment{},
+		&v1.PersistentVolumeClaim{},
+		&v1.Service{},
+		&appsv1.DaemonSet{},
+		&appsv1.StatefulSet{},
+		&ocappsv1.DeploymentConfig{},
+		&ocimgv1.ImageStream{},
+		&ocbuildv1.BuildConfig{},
+		&netv1.Ingress{},
+		&v1.Secret{},
+		&v1.ConfigMap{},
+		&v1.ServiceAccount{},
+		&rbacv1.Role{},
+		&rbacv1.RoleBinding{},
+	}
+}
+
+// clusterResourceTypes are the kinds of cluster-scoped resources managed by the operator which are
+// always watched.
+func clusterResourceTypes() []client.Object {
+	return []client.Object{
+		&v1.Namespace{},
+		&apiextensionsv1.CustomResourceDefinition{},
+		&apiregistrationv1.APIService{},
+		&admv1.MutatingWebhookConfiguration{},
+		&admv1.ValidatingWebhookConfiguration{},
+		&rbacv1.ClusterRole{},
+		&rbacv1.ClusterRoleBinding{},
+	}
+}
+
+// managedResourceSelector selects the resources labeled as managed for a KfDef, the only ones
+// the operator watches.
+var managedResourceSelector = labels.NewSelector().Add(mustRequirement(kfutils.KfDefNameLabel, selection.Exists))
+
+func mustRequirement(key string, op selection.Operator, values ...string) labels.Requirement {
+	req, err := labels.NewRequirement(key, op, values)
+	if err != nil {
+		panic(err)
+	}
+	return *req
+}
+
+// dynamicWatches watches the resources managed for the KfDefs, selected by their ownership labels
+// by the API server, with metadata-only informers. The kinds always watched are registered when
+// the controller is set up, the other kinds rendered for the KfDefs, e.g. Routes or custom
+// resources, at runtime, until no KfDef renders them anymore.
+type dynamicWatches struct {
+	mu         sync.Mutex
+	controller controller.Controller
+	mapper     meta.RESTMapper
+	// managedHandler maps the changes of the resources to their KfDef.
+	managedHandler handler.EventHandler
+	// informer returns the informer of the labeled resources of a kind, running until stop is closed.
+	informer func(gvr schema.GroupVersionResource, stop <-chan struct{}) cache.SharedIndexInformer
+	// stopped is set once the manager stops.
+	stopped bool
+	// watched are the stop channels of the informers of the kinds watched, by group and kind, so
+	// a kind rendered in several versions is only watched once.
+	watched map[schema.GroupKind]chan struct{}
+	// always are the kinds always watched.
+	always map[schema.GroupKind]bool
+	// kfdefs are the kinds rendered for each KfDef.
+	kfdefs map[types.NamespacedName]map[schema.GroupKind]bool
+}
+
+// newDynamicWatches returns the dynamic watches of the controller, listing the resources with the client.
+func newDynamicWatches(c controller.Controller, mapper meta.RESTMapper, client metadata.Interface,
+	managedHandler handler.EventHandler) *dynamicWatches {
+	return &dynamicWatches{
+		controller:     c,
+		mapper:         mapper,
+		managedHandler: managedHandler,
+		informer: func(gvr schema.GroupVersionResource, stop <-chan struct{}) cache.SharedIndexInformer {
+			informer := metadatainformer.NewFilteredMetadataInformer(client, gvr, metav1.NamespaceAll, 0, cache.Indexers{},
+				func(options *metav1.ListOptions) {
+					options.LabelSelector = managedResourceSelector.String()
+				}).Informer()
+			go informer.Run(stop)
+			return informer
+		},
+		watched: map[schema.GroupKind]chan struct{}{},
+		always:  map[schema.GroupKind]bool{},
+		kfdefs:  map[types.NamespacedName]map[schema.GroupKind]bool{},
+	}
+}
+
+// Start implements manager.Runnable, stopping the informers along with the manager.
+func (w *dynamicWatches) Start(ctx context.Context) error {
+	<-ctx.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for kind, stop := range w.watched {
+		close(stop)
+		delete(w.watched, kind)
+	}
+	w.stopped = true
+	return nil
+}
+
+// watch starts watching the kinds always watched.
+func (w *dynamicWatches) watch(kinds []metav1.GroupVersionKind) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, kind := range kinds {
+		w.always[schema.GroupKind{Group: kind.Group, Kind: kind.Kind}] = true
+	}
+	return w.start(kinds)
+}
+
+// watchKfDef starts watching the kinds rendered for the KfDef which aren't watched yet, and stops
+// watching the kinds no KfDef renders anymore.
+func (w *dynamicWatches) watchKfDef(key types.NamespacedName, kinds []metav1.GroupVersionKind) error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	rendered := map[schema.GroupKind]bool{}
+	for _, kind := range kinds {
+		rendered[schema.GroupKind{Group: kind.Group, Kind: kind.Kind}] = true
+	}
+	w.kfdefs[key] = rendered
+	err := w.start(kinds)
+	w.stopUnused()
+	return err
+}
+
+// forget stops watching the kinds only rendered for the deleted KfDef.
+func (w *dynamicWatches) forget(key types.NamespacedName) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.kfdefs, key)
+	w.stopUnused()
+}
+
+// start starts watching the kinds which aren't watched yet. Kinds the cluster doesn't serve yet,
+// e.g. custom resources whose CRD isn't applied yet, are watched once they are rendered again.
+func (w *dynamicWatches) start(kinds []metav1.GroupVersionKind) error {
+	if w.stopped {
+		return nil
+	}
+	errs := []error{}
+	for _, kind := range kinds {
+		gvk := schema.GroupVersionKind{Group: kind.Group, Version: kind.Version, Kind: kind.Kind}
+		if _, ok := w.watched[gvk.GroupKind()]; ok {
+			continue
+		}
+		mapping, err := w.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		stop := make(chan struct{})
+		informer := w.informer(mapping.Resource, stop)
+		if err := w.controller.Watch(&source.Informer{Informer: informer}, w.managedHandler, managedResourcePredicates); err != nil {
+			close(stop)
+			errs = append(errs, err)
+			continue
+		}
+		w.watched[gvk.GroupKind()] = stop
+	}
+	return errutil.NewAggregate(errs)
+}
+
+// stopUnused stops the informers of the kinds neither always watched nor rendered for a KfDef.
+// The controller keeps their sources, which no longer get any event.
+func (w *dynamicWatches) stopUnused() {
+	for kind, stop := range w.watched {
+		if w.always[kind] {
+			continue
+		}
+		used := false
+		for _, rendered := range w.kfdefs {
+			if rendered[kind] {
+				used = true
+				break
+			}
+		}
+		if !used {
+			close(stop)
+			delete(w.watched, kind)
+		}
+	}
+}
+
+// watchedKinds returns the kinds of the objects.
+func watchedKinds(scheme *runtime.Scheme, objs []client.Object) ([]metav1.GroupVersionKind, error) {
+	kinds := []metav1.GroupVersionKind{}
+	for _, obj := range objs {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind})
+	}
+	return kinds, nil
+}
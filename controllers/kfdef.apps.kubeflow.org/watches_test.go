@@ -0,0 +1,210 @@
+package kfdefappskubefloworg
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	kfdefv1 "github.com/opendatahub-io/opendatahub-operator/apis/kfdef.apps.kubeflow.org/v1"
+	kfutils "github.com/opendatahub-io/opendatahub-operator/pkg/utils"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// testController records the sources it's asked to watch.
+type testController struct {
+	watched []source.Source
+}
+
+func (c *testController) Reconcile(context.Context, reconcile.Request) (reconcile.Result, error) {
+	return reconcile.Result{}, nil
+}
+
+func (c *testController) Watch(src source.Source, _ handler.EventHandler, _ ...predicate.Predicate) error {
+	c.watched = append(c.watched, src.(*source.Informer))
+	return nil
+}
+
+func (c *testController) Start(context.Context) error {
+	return nil
+}
+
+func (c *testController) GetLogger() logr.Logger {
+	return logr.Discard()
+}
+
+func TestDynamicWatches(t *testing.T) {
+	route := schema.GroupVersionKind{Group: "route.openshift.io", Version: "v1", Kind: "Route"}
+	webhook := schema.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "MutatingWebhookConfiguration"}
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(route, meta.RESTScopeNamespace)
+	mapper.Add(webhook, meta.RESTScopeRoot)
+	mapper.Add(deployment, meta.RESTScopeNamespace)
+
+	c := &testController{}
+	watches := newDynamicWatches(c, mapper, nil, &handler.EnqueueRequestForObject{})
+	informers := []schema.GroupVersionResource{}
+	stops := map[schema.GroupVersionResource]<-chan struct{}{}
+	watches.informer = func(gvr schema.GroupVersionResource, stop <-chan struct{}) cache.SharedIndexInformer {
+		informers = append(informers, gvr)
+		stops[gvr] = stop
+		return cache.NewSharedIndexInformer(&cache.ListWatch{}, &metav1.PartialObjectMetadata{}, 0, cache.Indexers{})
+	}
+	if err := watches.watch([]metav1.GroupVersionKind{{Group: "apps", Version: "v1", Kind: "Deployment"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	kfdef := types.NamespacedName{Name: "opendatahub", Namespace: "odh"}
+	kinds := []metav1.GroupVersionKind{
+		{Group: "route.openshift.io", Version: "v1", Kind: "Route"},
+		{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "MutatingWebhookConfiguration"},
+		// already watched, in another version
+		{Group: "apps", Version: "v1beta1", Kind: "Deployment"},
+		// not served yet
+		{Group: "dashboard.opendatahub.io", Version: "v1", Kind: "OdhApplication"},
+	}
+	if err := watches.watchKfDef(kfdef, kinds); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// one watch of the labeled resources per kind
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	routes := schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+	webhooks := schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "mutatingwebhookconfigurations"}
+	expected := []schema.GroupVersionResource{deployments, routes, webhooks}
+	if !reflect.DeepEqual(informers, expected) || len(c.watched) != 3 {
+		t.Errorf("unexpected watches %v", informers)
+	}
+
+	// watched once served, and the other kinds only once
+	mapper.Add(schema.GroupVersionKind{Group: "dashboard.opendatahub.io", Version: "v1", Kind: "OdhApplication"}, meta.RESTScopeNamespace)
+	if err := watches.watchKfDef(kfdef, kinds); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	applications := schema.GroupVersionResource{Group: "dashboard.opendatahub.io", Version: "v1", Resource: "odhapplications"}
+	expected = append(expected, applications)
+	if !reflect.DeepEqual(informers, expected) || len(c.watched) != 4 {
+		t.Errorf("unexpected watches %v", informers)
+	}
+
+	// the kinds no KfDef renders anymore are no longer watched, unlike those always watched
+	other := types.NamespacedName{Name: "other", Namespace: "odh"}
+	if err := watches.watchKfDef(other, kinds[:1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := watches.watchKfDef(kfdef, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for gvr, stopped := range map[schema.GroupVersionResource]bool{
+		deployments: false, routes: false, webhooks: true, applications: true} {
+		if isClosed(stops[gvr]) != stopped {
+			t.Errorf("expected the informer of %v to be stopped: %v", gvr, stopped)
+		}
+	}
+	watches.forget(other)
+	if !isClosed(stops[routes]) || isClosed(stops[deployments]) {
+		t.Errorf("expected only the informer of the routes to be stopped")
+	}
+
+	// watched again once rendered again
+	if err := watches.watchKfDef(kfdef, kinds[:1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(informers) != 5 || informers[4] != routes || isClosed(stops[routes]) {
+		t.Errorf("unexpected watches %v", informers)
+	}
+
+	// no watches without a controller, e.g. in tests
+	var none *dynamicWatches
+	if err := none.watchKfDef(kfdef, kinds); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func isClosed(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestKfDefOwner(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := kfdefv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add KfDef to scheme; %v", err)
+	}
+	long := strings.Repeat("opendatahub", 6)
+	r := &KfDefReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(
+			&kfdefv1.KfDef{ObjectMeta: metav1.ObjectMeta{Name: "opendatahub", Namespace: "odh"}},
+			&kfdefv1.KfDef{ObjectMeta: metav1.ObjectMeta{Name: long, Namespace: "odh"}},
+		).Build(),
+		Log: logr.Discard(),
+	}
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		expected string
+	}{
+		{name: "labeled", labels: kfutils.OwnershipLabels("opendatahub", "odh", "dashboard"), expected: "opendatahub"},
+		{name: "long name", labels: kfutils.OwnershipLabels(long, "odh", "dashboard"), expected: long},
+		{name: "other namespace", labels: kfutils.OwnershipLabels("opendatahub", "other", "dashboard")},
+		{name: "unlabeled"},
+	}
+	for _, test := range tests {
+		obj := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "dashboard", Namespace: "odh", Labels: test.labels}}
+		instance, found := r.kfdefOwner(obj)
+		if found != (test.expected != "") {
+			t.Errorf("%v: unexpected owner %v", test.name, instance)
+			continue
+		}
+		if found && instance.Name != test.expected {
+			t.Errorf("%v: expected KfDef %v, got %v", test.name, test.expected, instance.Name)
+		}
+	}
+}
+
+func TestManagedResourcePredicates(t *testing.T) {
+	deployment := func(generation int64, labels map[string]string, annotations map[string]string) *metav1.PartialObjectMetadata {
+		return &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+			Name: "dashboard", Namespace: "odh", Generation: generation, Labels: labels, Annotations: annotations}}
+	}
+	labels := kfutils.OwnershipLabels("opendatahub", "odh", "dashboard")
+	tests := []struct {
+		name     string
+		old      client.Object
+		new      client.Object
+		expected bool
+	}{
+		{name: "spec", old: deployment(1, labels, nil), new: deployment(2, labels, nil), expected: true},
+		{name: "labels", old: deployment(1, labels, nil), new: deployment(1, nil, nil), expected: true},
+		{name: "annotations", old: deployment(1, labels, nil), new: deployment(1, labels, map[string]string{"a": "b"}), expected: true},
+		{name: "status", old: deployment(1, labels, nil), new: deployment(1, labels, nil)},
+	}
+	for _, test := range tests {
+		if updated := managedResourcePredicates.Update(event.UpdateEvent{ObjectOld: test.old, ObjectNew: test.new}); updated != test.expected {
+			t.Errorf("%v: expected %v, got %v", test.name, test.expected, updated)
+		}
+	}
+}
+
+func TestManagedResourceSelector(t *testing.T) {
+	if selector := managedResourceSelector.String(); selector != kfutils.KfDefNameLabel {
+		t.Errorf("unexpected selector %v", selector)
+	}
+}
@@ -41,6 +41,7 @@ import (
 	"github.com/ghodss/yaml"
 	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
 	kftypes "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/platforms"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig/awsplugin"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/utils"
@@ -97,6 +98,10 @@ type manifest struct {
 	path string
 }
 
+func init() {
+	platforms.Register(kfconfig.AWS_PLUGIN_KIND, kftypes.AWS, GetPlatform)
+}
+
 // GetKfApp returns the aws kfapp. It's called by coordinator.GetKfApp
 func GetPlatform(kfdef *kfconfig.KfConfig) (kftypes.Platform, error) {
 	// Manifest lists are used in `Delete` to make sure we track and clean up all the resources.
@@ -25,11 +25,8 @@ import (
 
 	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
 	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
-	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/aws"
-	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/existing_arrikto"
-	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/gcp"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/kustomize"
-	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/minikube"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/platforms"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
 	kfconfigloaders "github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig/loaders"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/utils"
@@ -49,41 +46,31 @@ func (b *DefaultBuilder) LoadKfAppCfgFile(cfgFile string) (kftypesv3.KfApp, erro
 	return NewLoadKfAppFromURI(cfgFile)
 }
 
-// GetPlatform will return an implementation of kftypesv3.GetPlatform that matches the platform string
-// It looks for statically compiled-in implementations, otherwise throws unrecognized error
+// getPlatform will return an implementation of kftypesv3.Platform that matches the platform string
+// It looks for the platforms registered in the platforms package, otherwise returns an unrecognized error
 func getPlatform(kfdef *kfconfig.KfConfig) (kftypesv3.Platform, error) {
-	switch kfdef.Spec.Platform {
-	case string(kftypesv3.MINIKUBE):
-		return minikube.Getplatform(kfdef), nil
-	case string(kftypesv3.GCP):
-		return gcp.GetPlatform(kfdef)
-	case string(kftypesv3.EXISTING_ARRIKTO):
-		return existing_arrikto.GetPlatform(kfdef)
-	case string(kftypesv3.AWS):
-		return aws.GetPlatform(kfdef)
-	default:
-		// TODO(https://github.com/kubeflow/kubeflow/issues/3520) Fix dynamic loading
-		// of platform plugins.
-		log.Infof("** Unrecognized platform %v **", kfdef.Spec.Platform)
-		return nil, fmt.Errorf("Unrecognized platform %v", kfdef.Spec.Platform)
+	p, err := platforms.GetPlatform(kfdef)
+	if err != nil {
+		log.Infof("** Could not get platform %v: %v **", kfdef.Spec.Platform, err)
 	}
+	return p, err
 }
 
-func (coord *coordinator) getPackageManagers(kfdef *kfconfig.KfConfig) *map[string]kftypesv3.KfApp {
+func (coord *coordinator) getPackageManagers(kfdef *kfconfig.KfConfig) (*map[string]kftypesv3.KfApp, error) {
 	var packagemanagers = make(map[string]kftypesv3.KfApp)
 	_packagemanager, _packagemanagerErr := getPackageManager(kfdef)
 	if _packagemanagerErr != nil {
-		log.Fatalf("Could not get packagemanager %v: %v **", kftypesv3.KUSTOMIZE, _packagemanagerErr)
+		return nil, fmt.Errorf("could not get packagemanager %v: %v", kftypesv3.KUSTOMIZE, _packagemanagerErr)
 	}
 	if _packagemanager != nil {
 		packagemanagers[kftypesv3.KUSTOMIZE] = _packagemanager
 	}
-	return &packagemanagers
+	return &packagemanagers, nil
 }
 
 // getPackageManager will return an implementation of kftypesv3.KfApp that matches the packagemanager string
-// It looks for statically compiled-in implementations, otherwise it delegates to
-// kftypesv3.LoadKfApp which will try and dynamically load a .so
+// The kustomize KfApp renders every application with the renderer registered for its source,
+// e.g. a kustomize package or a Helm chart, in the renderers package.
 //
 func getPackageManager(kfdef *kfconfig.KfConfig) (kftypesv3.KfApp, error) {
 	return kustomize.GetKfApp(kfdef), nil
@@ -211,7 +198,6 @@ func NewLoadKfAppFromURI(configFile string) (kftypesv3.KfApp, error) {
 	if platform != "" {
 		_platform, _platformErr := getPlatform(c.KfDef)
 		if _platformErr != nil {
-			log.Fatalf("Could not get platform %v: %v **", platform, _platformErr)
 			return nil, _platformErr
 		}
 		if _platform != nil {
@@ -220,7 +206,7 @@ func NewLoadKfAppFromURI(configFile string) (kftypesv3.KfApp, error) {
 	}
 	pkg, pkgErr := getPackageManager(c.KfDef)
 	if pkgErr != nil {
-		log.Fatalf("Could not get package manager %v: %v **", kftypesv3.KUSTOMIZE, pkgErr)
+		log.Errorf("Could not get package manager %v: %v **", kftypesv3.KUSTOMIZE, pkgErr)
 		return nil, pkgErr
 	}
 	if pkg != nil {
@@ -337,12 +323,31 @@ func (kfapp *coordinator) Dump(resources kftypesv3.ResourceEnum) error {
 	return nil
 }
 
+// setPlatformCondition records the result of applying the platform as the Succeeded or Failed
+// condition of its plugin kind. The config file is written so the conditions outlive the KfApp,
+// e.g. for the operator to copy them into the KfDef status. It returns err.
+func (kfapp *coordinator) setPlatformCondition(err error) error {
+	kind, ok := platforms.Kind(kfapp.KfDef.Spec.Platform)
+	if !ok {
+		return err
+	}
+	if err != nil {
+		kfapp.KfDef.SetPluginFailed(kind, err.Error())
+	} else {
+		kfapp.KfDef.SetPluginFinished(kind, fmt.Sprintf("%v platform applied", kfapp.KfDef.Spec.Platform))
+	}
+	if writeErr := kfconfigloaders.WriteConfigToFile(*kfapp.KfDef); writeErr != nil {
+		log.Warnf("Could not write the %v conditions to the config file: %v", kind, writeErr)
+	}
+	return err
+}
+
 func (kfapp *coordinator) Apply(resources kftypesv3.ResourceEnum) error {
 	platform := func() error {
 		if kfapp.KfDef.Spec.Platform != "" {
 			platform := kfapp.Platforms[kfapp.KfDef.Spec.Platform]
 			if platform != nil {
-				platformErr := platform.Apply(resources)
+				platformErr := kfapp.setPlatformCondition(platform.Apply(resources))
 				if platformErr != nil {
 					return &kfapis.KfError{
 						Code: int(kfapis.INTERNAL_ERROR),
@@ -383,24 +388,14 @@ func (kfapp *coordinator) Apply(resources kftypesv3.ResourceEnum) error {
 		return nil
 	}
 
-	gcpAddedConfig := func() error {
-		if kfapp.KfDef.Spec.Email == "" || kfapp.KfDef.Spec.Platform != kftypesv3.GCP {
+	// The resources the platform sets up along with the applications, e.g. the GCP workload
+	// identity permissions or the OpenShift routes.
+	platformK8S := func() error {
+		p, ok := kfapp.Platforms[kfapp.KfDef.Spec.Platform].(platforms.K8SPlatform)
+		if !ok {
 			return nil
 		}
-
-		if p, ok := kfapp.Platforms[kfapp.KfDef.Spec.Platform]; !ok {
-			return &kfapis.KfError{
-				Code:    int(kfapis.INTERNAL_ERROR),
-				Message: "Platform GCP specified but not loaded.",
-			}
-		} else {
-			gcp := p.(*gcp.Gcp)
-			if err := gcp.SetupWorkloadIdentityPermission(); err != nil {
-				return err
-			}
-			// Keep podDefault for backward compatibility
-			return gcp.ConfigPodDefault()
-		}
+		return kfapp.setPlatformCondition(p.ApplyK8S())
 	}
 
 	if err := kfapp.KfDef.SyncCache(); err != nil {
@@ -418,16 +413,14 @@ func (kfapp *coordinator) Apply(resources kftypesv3.ResourceEnum) error {
 		if err := k8s(); err != nil {
 			return err
 		}
-		return gcpAddedConfig()
+		return platformK8S()
 	case kftypesv3.PLATFORM:
 		return platform()
 	case kftypesv3.K8S:
 		if err := k8s(); err != nil {
 			return err
 		}
-		// TODO(gabrielwen): Need to find a more proper way of injecting plugings.
-		// https://github.com/kubeflow/kubeflow/issues/3708
-		return gcpAddedConfig()
+		return platformK8S()
 	}
 	return nil
 }
@@ -467,6 +460,16 @@ func (kfapp *coordinator) Delete(resources kftypesv3.ResourceEnum) error {
 				}
 			}
 		}
+		// The resources the platform set up along with the applications are deleted with them.
+		if p, ok := kfapp.Platforms[kfapp.KfDef.Spec.Platform].(platforms.K8SPlatform); ok {
+			if err := p.DeleteK8S(); err != nil {
+				return &kfapis.KfError{
+					Code: int(kfapis.INTERNAL_ERROR),
+					Message: fmt.Sprintf("coordinator Delete failed for %v: %v",
+						kfapp.KfDef.Spec.Platform, err),
+				}
+			}
+		}
 		return nil
 	}
 
@@ -2,6 +2,7 @@ package coordinator
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path"
@@ -10,6 +11,7 @@ import (
 
 	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	kfconfigloaders "github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig/loaders"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -170,6 +172,239 @@ func Test_nameFromAppFile(t *testing.T) {
 	}
 }
 
+func Test_getPlatform(t *testing.T) {
+	type testCase struct {
+		platform    string
+		expectedErr bool
+	}
+
+	testCases := []testCase{
+		{platform: kftypesv3.GCP},
+		{platform: kftypesv3.MINIKUBE},
+		{platform: kftypesv3.OPENSHIFT},
+		{platform: "unknown", expectedErr: true},
+	}
+
+	for _, c := range testCases {
+		p, err := getPlatform(&kfconfig.KfConfig{Spec: kfconfig.KfConfigSpec{Platform: c.platform}})
+		if c.expectedErr {
+			if err == nil {
+				t.Errorf("getPlatform(%v); expected an error", c.platform)
+			}
+			continue
+		}
+		if err != nil || p == nil {
+			t.Errorf("getPlatform(%v); got %v, %v", c.platform, p, err)
+		}
+	}
+}
+
+func Test_setPlatformCondition(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	kfdef := &kfconfig.KfConfig{
+		TypeMeta: metav1.TypeMeta{APIVersion: "kfdef.apps.kubeflow.org/v1"},
+		Spec: kfconfig.KfConfigSpec{
+			AppDir:         testDir,
+			ConfigFileName: kftypesv3.KfConfigFile,
+			Platform:       kftypesv3.GCP,
+		},
+	}
+	kfapp := &coordinator{KfDef: kfdef}
+
+	if err := kfapp.setPlatformCondition(nil); err != nil {
+		t.Fatalf("setPlatformCondition(nil); got %v", err)
+	}
+	if !kfdef.IsPluginFinished(kfconfig.GCP_PLUGIN_KIND) {
+		t.Errorf("expected the gcp plugin to be finished")
+	}
+
+	if err := kfapp.setPlatformCondition(errors.New("failed")); err == nil {
+		t.Fatalf("expected setPlatformCondition to return the error")
+	}
+	written, err := kfconfigloaders.LoadConfigFromURI(path.Join(testDir, kftypesv3.KfConfigFile))
+	if err != nil {
+		t.Fatalf("could not load the config file; %v", err)
+	}
+	if !written.IsPluginFailed(kfconfig.GCP_PLUGIN_KIND) || written.IsPluginFinished(kfconfig.GCP_PLUGIN_KIND) {
+		t.Errorf("expected the gcp plugin to be failed in the config file; got %v", written.Status.Conditions)
+	}
+}
+
+// fakeKfApp records the order it's applied and deleted in.
+type fakeKfApp struct {
+	name  string
+	calls *[]string
+	err   error
+}
+
+func (f *fakeKfApp) Apply(resources kftypesv3.ResourceEnum) error {
+	*f.calls = append(*f.calls, "apply "+f.name)
+	return f.err
+}
+
+func (f *fakeKfApp) Delete(resources kftypesv3.ResourceEnum) error {
+	*f.calls = append(*f.calls, "delete "+f.name)
+	return f.err
+}
+
+func (f *fakeKfApp) Dump(resources kftypesv3.ResourceEnum) error     { return nil }
+func (f *fakeKfApp) Generate(resources kftypesv3.ResourceEnum) error { return nil }
+func (f *fakeKfApp) Init(resources kftypesv3.ResourceEnum) error     { return nil }
+
+// fakeK8SPlatform is a platform setting up resources along with the applications.
+type fakeK8SPlatform struct {
+	fakeKfApp
+}
+
+func (f *fakeK8SPlatform) ApplyK8S() error {
+	*f.calls = append(*f.calls, "apply k8s "+f.name)
+	return f.err
+}
+
+func (f *fakeK8SPlatform) DeleteK8S() error {
+	*f.calls = append(*f.calls, "delete k8s "+f.name)
+	return f.err
+}
+
+func Test_ApplyOrder(t *testing.T) {
+	type testCase struct {
+		platform      string
+		k8s           bool
+		resources     kftypesv3.ResourceEnum
+		delete        bool
+		expectedCalls []string
+	}
+
+	testCases := []testCase{
+		{
+			// Only the K8S resources are applied, e.g. by the operator, not the platform.
+			platform:      kftypesv3.AWS,
+			resources:     kftypesv3.K8S,
+			expectedCalls: []string{"apply kustomize"},
+		},
+		{
+			platform:      kftypesv3.AWS,
+			resources:     kftypesv3.ALL,
+			expectedCalls: []string{"apply aws", "apply kustomize"},
+		},
+		{
+			platform:      kftypesv3.OPENSHIFT,
+			k8s:           true,
+			resources:     kftypesv3.K8S,
+			expectedCalls: []string{"apply kustomize", "apply k8s openshift"},
+		},
+		{
+			platform:      kftypesv3.GCP,
+			k8s:           true,
+			resources:     kftypesv3.ALL,
+			expectedCalls: []string{"apply gcp", "apply kustomize", "apply k8s gcp"},
+		},
+		{
+			platform:      kftypesv3.MINIKUBE,
+			resources:     kftypesv3.PLATFORM,
+			expectedCalls: []string{"apply minikube"},
+		},
+		{
+			platform:      kftypesv3.OPENSHIFT,
+			k8s:           true,
+			resources:     kftypesv3.PLATFORM,
+			expectedCalls: []string{"apply openshift"},
+		},
+		{
+			platform:      kftypesv3.OPENSHIFT,
+			k8s:           true,
+			resources:     kftypesv3.K8S,
+			delete:        true,
+			expectedCalls: []string{"delete kustomize", "delete k8s openshift"},
+		},
+		{
+			platform:      kftypesv3.MINIKUBE,
+			resources:     kftypesv3.K8S,
+			delete:        true,
+			expectedCalls: []string{"delete kustomize"},
+		},
+	}
+
+	for _, c := range testCases {
+		testDir, _ := ioutil.TempDir("", "")
+		defer os.RemoveAll(testDir)
+
+		var calls []string
+		var platform kftypesv3.Platform = &fakeKfApp{name: c.platform, calls: &calls}
+		if c.k8s {
+			platform = &fakeK8SPlatform{fakeKfApp{name: c.platform, calls: &calls}}
+		}
+		kfapp := &coordinator{
+			Platforms: map[string]kftypesv3.Platform{
+				c.platform: platform,
+			},
+			PackageManagers: map[string]kftypesv3.KfApp{
+				kftypesv3.KUSTOMIZE: &fakeKfApp{name: kftypesv3.KUSTOMIZE, calls: &calls},
+			},
+			KfDef: &kfconfig.KfConfig{
+				TypeMeta: metav1.TypeMeta{APIVersion: "kfdef.apps.kubeflow.org/v1"},
+				Spec: kfconfig.KfConfigSpec{
+					AppDir:         testDir,
+					ConfigFileName: kftypesv3.KfConfigFile,
+					Platform:       c.platform,
+				},
+			},
+		}
+		var err error
+		if c.delete {
+			err = kfapp.Delete(c.resources)
+		} else {
+			err = kfapp.Apply(c.resources)
+		}
+		if err != nil {
+			t.Fatalf("%v %v: unexpected error; %v", c.platform, c.resources, err)
+		}
+		if !reflect.DeepEqual(calls, c.expectedCalls) {
+			t.Errorf("%v %v: calls; got %v; want %v", c.platform, c.resources, calls, c.expectedCalls)
+		}
+	}
+}
+
+func Test_ApplyFailedPlatform(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	var calls []string
+	kfdef := &kfconfig.KfConfig{
+		TypeMeta: metav1.TypeMeta{APIVersion: "kfdef.apps.kubeflow.org/v1"},
+		Spec: kfconfig.KfConfigSpec{
+			AppDir:         testDir,
+			ConfigFileName: kftypesv3.KfConfigFile,
+			Platform:       kftypesv3.AWS,
+		},
+	}
+	kfapp := &coordinator{
+		Platforms: map[string]kftypesv3.Platform{
+			kftypesv3.AWS: &fakeKfApp{name: kftypesv3.AWS, calls: &calls, err: errors.New("no credentials")},
+		},
+		PackageManagers: map[string]kftypesv3.KfApp{
+			kftypesv3.KUSTOMIZE: &fakeKfApp{name: kftypesv3.KUSTOMIZE, calls: &calls},
+		},
+		KfDef: kfdef,
+	}
+
+	if err := kfapp.Apply(kftypesv3.ALL); err == nil {
+		t.Fatalf("expected applying a failing platform to be an error")
+	}
+	if !reflect.DeepEqual(calls, []string{"apply aws"}) {
+		t.Errorf("calls; got %v", calls)
+	}
+	written, err := kfconfigloaders.LoadConfigFromURI(path.Join(testDir, kftypesv3.KfConfigFile))
+	if err != nil {
+		t.Fatalf("could not load the config file; %v", err)
+	}
+	if !written.IsPluginFailed(kfconfig.AWS_PLUGIN_KIND) {
+		t.Errorf("expected the aws plugin to be failed in the config file; got %v", written.Status.Conditions)
+	}
+}
+
 // Pformat returns a pretty format output of any value.
 func Pformat(value interface{}) (string, error) {
 	if s, ok := value.(string); ok {
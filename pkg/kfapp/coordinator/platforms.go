@@ -0,0 +1,12 @@
+package coordinator
+
+// The platforms a KfApp can be deployed to. Each platform package registers itself in the
+// platforms package when it's linked in, so platforms are added or removed by editing this list.
+// gcp is also imported by the coordinator to set up workload identity.
+import (
+	_ "github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/aws"
+	_ "github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/existing_arrikto"
+	_ "github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/gcp"
+	_ "github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/minikube"
+	_ "github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/openshift"
+)
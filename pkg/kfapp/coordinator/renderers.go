@@ -0,0 +1,9 @@
+package coordinator
+
+// The renderers applications can be rendered with. Each renderer package registers itself in the
+// renderers package when it's linked in, so source types are added or removed by editing this list.
+// The kustomize renderer is registered by the kustomize package manager.
+import (
+	_ "github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers/helm"
+	_ "github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers/manifests"
+)
@@ -21,6 +21,7 @@ import (
 
 	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
 	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/platforms"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/utils"
 	"github.com/pkg/errors"
@@ -55,6 +56,10 @@ type manifest struct {
 	path string
 }
 
+func init() {
+	platforms.Register(kfconfig.EXISTING_ARRIKTO_PLUGIN_KIND, kftypesv3.EXISTING_ARRIKTO, GetPlatform)
+}
+
 func GetPlatform(kfdef *kfconfig.KfConfig) (kftypesv3.Platform, error) {
 
 	istioManifestsDir := path.Join(CONFIG_LOCAL_PATH, "istio")
@@ -37,6 +37,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
 	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/platforms"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig/gcpplugin"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/utils"
@@ -141,7 +142,11 @@ type dmOperationEntry struct {
 	action string
 }
 
-// GetPlatform returns the gcp kfapp. It's called by coordinator.GetPlatform
+func init() {
+	platforms.Register(kfconfig.GCP_PLUGIN_KIND, kftypesv3.GCP, GetPlatform)
+}
+
+// GetPlatform returns the gcp kfapp. It's registered as the gcp platform in the platforms package.
 func GetPlatform(kfdef *kfconfig.KfConfig) (kftypesv3.Platform, error) {
 
 	_gcp := &Gcp{
@@ -1807,6 +1812,24 @@ func createOrUpdateK8sServiceAccount(k8sClientset *clientset.Clientset, namespac
 	}
 }
 
+// ApplyK8S implements platforms.K8SPlatform: the workload identity permissions and the PodDefault
+// of the user are set up once the applications are applied.
+func (gcp *Gcp) ApplyK8S() error {
+	if gcp.kfDef.Spec.Email == "" {
+		return nil
+	}
+	if err := gcp.SetupWorkloadIdentityPermission(); err != nil {
+		return err
+	}
+	// Keep podDefault for backward compatibility
+	return gcp.ConfigPodDefault()
+}
+
+// DeleteK8S implements platforms.K8SPlatform. What ApplyK8S sets up is deleted with the applications.
+func (gcp *Gcp) DeleteK8S() error {
+	return nil
+}
+
 // SetupWorkloadIdentityPermission bind gcp admin service account to owner of gcp "user" service account, so controller can edit WorkloadIdentity
 func (gcp *Gcp) SetupWorkloadIdentityPermission() error {
 	kubeflowWorkloadIdentityMapping := map[string]string{
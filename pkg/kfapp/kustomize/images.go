@@ -0,0 +1,129 @@
+package kustomize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"sigs.k8s.io/kustomize/v3/pkg/image"
+	"sigs.k8s.io/kustomize/v3/pkg/resmap"
+	"sigs.k8s.io/kustomize/v3/plugin/builtin"
+)
+
+// applicationImages returns the image overrides of the application: the ones of the KfDef,
+// replaced by the ones of the application for the same image.
+func applicationImages(config *kfconfig.KfConfig, app kfconfig.Application) []kfconfig.Image {
+	images := []kfconfig.Image{}
+	overridden := map[string]bool{}
+	for _, img := range app.Images {
+		overridden[img.Name] = true
+	}
+	for _, img := range config.Spec.Images {
+		if !overridden[img.Name] {
+			images = append(images, img)
+		}
+	}
+	return append(images, app.Images...)
+}
+
+// transformImages applies the image overrides to the containers and init containers of the
+// resources of the application, using the kustomize images transform.
+func transformImages(config *kfconfig.KfConfig, app kfconfig.Application, resMap resmap.ResMap, _ *renderReport) error {
+	for _, img := range applicationImages(config, app) {
+		transformer := &builtin.ImageTagTransformerPlugin{
+			ImageTag: image.Image{
+				Name:    img.Name,
+				NewName: img.NewName,
+				NewTag:  img.NewTag,
+				Digest:  img.Digest,
+			},
+		}
+		if err := transformer.Transform(resMap); err != nil {
+			return &kfapisv3.KfError{
+				Code:    int(kfapisv3.INTERNAL_ERROR),
+				Message: fmt.Sprintf("couldn't override image %v of application %v: %v", img.Name, app.Name, err),
+			}
+		}
+	}
+	return nil
+}
+
+// splitImage splits an image reference into its name and its tag or digest, along with its separator.
+func splitImage(ref string) (string, string) {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i], ref[i:]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i:]
+	}
+	return ref, ""
+}
+
+// overrideImage returns the image reference with the first override of its name applied,
+// and false if none of them applies.
+func overrideImage(ref string, images []kfconfig.Image) (string, bool) {
+	name, tag := splitImage(ref)
+	for _, img := range images {
+		if img.Name != name {
+			continue
+		}
+		if img.NewName != "" {
+			name = img.NewName
+		}
+		if img.NewTag != "" {
+			tag = ":" + img.NewTag
+		}
+		if img.Digest != "" {
+			tag = "@" + img.Digest
+		}
+		return name + tag, true
+	}
+	return ref, false
+}
+
+// overrideParamImages applies the image overrides to the values of the params.env files of the
+// base and the overlays of the component at compDir, so they also apply where these parameters
+// aren't used as container images, e.g. in ConfigMaps or custom resources.
+func overrideParamImages(compDir string, overlays []string, images []kfconfig.Image) error {
+	if len(images) == 0 {
+		return nil
+	}
+	for _, dir := range paramDirs(compDir, overlays) {
+		paramFile := filepath.Join(dir, kftypesv3.KustomizationParamFile)
+		if _, err := os.Stat(paramFile); err != nil {
+			continue
+		}
+		lines, err := readLines(paramFile)
+		if err != nil {
+			return &kfapisv3.KfError{
+				Code:    int(kfapisv3.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("could not open %v: %v", paramFile, err),
+			}
+		}
+		changed := false
+		for i, line := range lines {
+			nameValue := strings.SplitN(line, "=", 2)
+			if len(nameValue) != 2 {
+				continue
+			}
+			if value, ok := overrideImage(nameValue[1], images); ok {
+				lines[i] = nameValue[0] + "=" + value
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if err := writeLines(lines, paramFile); err != nil {
+			return &kfapisv3.KfError{
+				Code:    int(kfapisv3.INTERNAL_ERROR),
+				Message: fmt.Sprintf("could not update %v: %v", paramFile, err),
+			}
+		}
+	}
+	return nil
+}
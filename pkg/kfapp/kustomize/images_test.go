@@ -0,0 +1,105 @@
+package kustomize
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTransformImages(t *testing.T) {
+	resMap := newTestResMap(t, testDeployment)
+	config := &kfconfig.KfConfig{
+		Spec: kfconfig.KfConfigSpec{
+			Images: []kfconfig.Image{
+				{Name: "quay.io/opendatahub/odh-dashboard", NewName: "mirror.example.com/odh-dashboard"},
+				{Name: "registry.access.redhat.com/ubi8/ubi-minimal", NewName: "mirror.example.com/ubi-minimal"},
+			},
+		},
+	}
+	app := kfconfig.Application{
+		Name: "dashboard",
+		Images: []kfconfig.Image{
+			{Name: "quay.io/opendatahub/odh-dashboard", NewTag: "v1.1"},
+		},
+	}
+	if err := transformImages(config, app, resMap, &renderReport{}); err != nil {
+		t.Fatalf("Failed to transform images: %v", err)
+	}
+
+	deployment := resourceMap(t, resMap, "Deployment")
+	containers, _, _ := unstructured.NestedSlice(deployment, "spec", "template", "spec", "containers")
+	initContainers, _, _ := unstructured.NestedSlice(deployment, "spec", "template", "spec", "initContainers")
+	// The override of the application replaces the one of the KfDef for the same image.
+	if image := containers[0].(map[string]interface{})["image"]; image != "quay.io/opendatahub/odh-dashboard:v1.1" {
+		t.Errorf("Unexpected container image; got %v", image)
+	}
+	if image := initContainers[0].(map[string]interface{})["image"]; image != "mirror.example.com/ubi-minimal:latest" {
+		t.Errorf("Unexpected init container image; got %v", image)
+	}
+}
+
+func TestOverrideImage(t *testing.T) {
+	images := []kfconfig.Image{
+		{Name: "quay.io/opendatahub/odh-dashboard", NewName: "mirror.example.com:5000/odh-dashboard"},
+		{Name: "registry.example.com:5000/notebook", Digest: "sha256:0123"},
+	}
+	type testCase struct {
+		ref      string
+		expected string
+		ok       bool
+	}
+	testCases := []testCase{
+		{ref: "quay.io/opendatahub/odh-dashboard:v1.0", expected: "mirror.example.com:5000/odh-dashboard:v1.0", ok: true},
+		{ref: "quay.io/opendatahub/odh-dashboard", expected: "mirror.example.com:5000/odh-dashboard", ok: true},
+		{ref: "registry.example.com:5000/notebook:py3", expected: "registry.example.com:5000/notebook@sha256:0123", ok: true},
+		{ref: "registry.example.com:5000/notebook@sha256:4567", expected: "registry.example.com:5000/notebook@sha256:0123", ok: true},
+		{ref: "quay.io/opendatahub/odh-dashboard-extra:v1.0", expected: "quay.io/opendatahub/odh-dashboard-extra:v1.0"},
+		{ref: "true", expected: "true"},
+	}
+	for _, c := range testCases {
+		actual, ok := overrideImage(c.ref, images)
+		if actual != c.expected || ok != c.ok {
+			t.Errorf("overrideImage(%v); got %v, %v; want %v, %v", c.ref, actual, ok, c.expected, c.ok)
+		}
+	}
+}
+
+func TestOverrideParamImages(t *testing.T) {
+	testDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+
+	baseDir := path.Join(testDir, "base")
+	objects, err := renderers.DecodeObjects([]byte(testDeployment))
+	if err != nil {
+		t.Fatalf("couldn't decode deployment: %v", err)
+	}
+	if err := writeResourcesPackage(baseDir, "dashboard", objects); err != nil {
+		t.Fatalf("Failed to write package: %v", err)
+	}
+	paramFile := path.Join(baseDir, "params.env")
+	if err := writeLines([]string{"namespace=opendatahub", "dashboard-image=quay.io/opendatahub/odh-dashboard:v1.0"}, paramFile); err != nil {
+		t.Fatalf("Failed to write %v: %v", paramFile, err)
+	}
+
+	images := []kfconfig.Image{{Name: "quay.io/opendatahub/odh-dashboard", NewName: "mirror.example.com/odh-dashboard"}}
+	if err := overrideParamImages(testDir, nil, images); err != nil {
+		t.Fatalf("Failed to override param images: %v", err)
+	}
+	actual, err := readLines(paramFile)
+	if err != nil {
+		t.Fatalf("Failed to read %v: %v", paramFile, err)
+	}
+	expected := []string{"namespace=opendatahub", "dashboard-image=mirror.example.com/odh-dashboard:v1.0"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected params; got %v; want %v", actual, expected)
+	}
+}
@@ -34,7 +34,10 @@ import (
 	"strings"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	errutil "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/cenkalti/backoff"
@@ -43,17 +46,19 @@ import (
 	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
 	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
 	kfdefsv3 "github.com/opendatahub-io/opendatahub-operator/apis/kfdef.apps.kubeflow.org/v1"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/utils"
-	"github.com/otiai10/copy"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
 	crdclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	rbacv1 "k8s.io/client-go/kubernetes/typed/rbac/v1"
 	"k8s.io/client-go/rest"
@@ -159,7 +164,130 @@ func (kustomize *kustomize) initK8sClients() error {
 	return nil
 }
 
-func (kustomize *kustomize) render(app kfconfig.Application) ([]byte, error) {
+// copySecrets copies the Secrets the render transformers reported, e.g. image pull secrets,
+// into the namespaces of the application once it's applied, since they may be created by it.
+func (kustomize *kustomize) copySecrets(copies []secretCopy) error {
+	if err := kustomize.initK8sClients(); err != nil {
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("kustomize plugin couldn't initialize a K8s client: %v", err),
+		}
+	}
+	kubeClient, err := kubernetes.NewForConfig(kustomize.restConfig)
+	if err != nil {
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't get a K8s client: %v", err),
+		}
+	}
+	// The copies aren't labeled with the application, which doesn't render them, so they aren't
+	// pruned when it's applied. They are deleted with the KfDef.
+	labels := utils.OwnershipLabels(kustomize.kfDef.Name, kustomize.kfDef.Namespace, "")
+	return copySecrets(kubeClient, copies, labels)
+}
+
+// annotationEnabled returns true if the kfctl.kubeflow.io annotation of the KfDef is set to true.
+func (kustomize *kustomize) annotationEnabled(name string) bool {
+	value, ok := kustomize.kfDef.GetAnnotations()[strings.Join([]string{utils.KfDefAnnotation, name}, "/")]
+	if !ok {
+		return false
+	}
+	enabled, err := strconv.ParseBool(value)
+	return err == nil && enabled
+}
+
+// selectedApplications returns the applications listed by the applications annotation of the
+// KfDef, or nil if it isn't set and all the applications are generated and applied.
+func (kustomize *kustomize) selectedApplications() sets.String {
+	value := kustomize.kfDef.GetAnnotations()[strings.Join([]string{utils.KfDefAnnotation, utils.SelectedApplications}, "/")]
+	if value == "" {
+		return nil
+	}
+	return sets.NewString(strings.Split(value, ",")...)
+}
+
+// applicationSelected returns true if the application is generated and applied.
+func applicationSelected(selected sets.String, name string) bool {
+	return selected == nil || selected.Has(name)
+}
+
+// operatorOwner sets the KfDef as the owner of the resources rendered when installed through the
+// operator. It's created once per Dump or Apply, since discovering the kinds the cluster serves
+// for its REST mapper is expensive.
+type operatorOwner struct {
+	instance   *unstructured.Unstructured
+	mapper     *restmapper.DeferredDiscoveryRESTMapper
+	namespaces corev1.NamespaceInterface
+	// stale is true when an application rendered before serves new kinds, e.g. with its CRDs,
+	// so the kinds are discovered again.
+	stale bool
+}
+
+// newOperatorOwner returns the owner of the resources rendered, or nil if they aren't installed
+// through the operator.
+func (kustomize *kustomize) newOperatorOwner() (*operatorOwner, error) {
+	// check to set owner references for resources if installed through kubeflow operator
+	if !kustomize.annotationEnabled(utils.SetAnnotation) {
+		return nil, nil
+	}
+	// retrieve the UID of the KfDef resource using dynamic client
+	config, _ := rest.InClusterConfig()
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("failed to create dynamic client: %v", err),
+		}
+	}
+	kfDefRes := schema.GroupVersionResource{Group: "kfdef.apps.kubeflow.org", Version: "v1", Resource: "kfdefs"}
+	instance, err := dyn.Resource(kfDefRes).Namespace(kustomize.kfDef.GetNamespace()).Get(context.TODO(), kustomize.kfDef.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("failed to get the KfDef object: %v", err),
+		}
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("failed to create discovery client: %v", err),
+		}
+	}
+	corev1client, err := corev1.NewForConfig(config)
+	if err != nil {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("failed to create corev1 client: %v", err),
+		}
+	}
+	return &operatorOwner{
+		instance:   instance,
+		mapper:     restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(dc)),
+		namespaces: corev1client.Namespaces(),
+	}, nil
+}
+
+// generate encodes the resources of the application with the operator annotation and the KfDef
+// as their owner.
+func (o *operatorOwner) generate(resMap resmap.ResMap, app string, labels map[string]string,
+	annotations map[string]string) ([]byte, error) {
+	if o.stale {
+		o.mapper.Reset()
+		o.stale = false
+	}
+	for _, r := range resMap.Resources() {
+		if kind := r.GetKind(); kind == "CustomResourceDefinition" || kind == "APIService" {
+			o.stale = true
+		}
+	}
+	return GenerateYamlWithOperatorAnnotation(resMap, o.instance, o.mapper, o.namespaces, app, labels, annotations)
+}
+
+// render evaluates the kustomize package of the application and applies the render transformers
+// to its resources. What the transformers report is added to report. The resources are owned by
+// owner if not nil.
+func (kustomize *kustomize) render(app kfconfig.Application, report *renderReport, owner *operatorOwner) ([]byte, error) {
 	kustomizeDir := path.Join(kustomize.kfDef.Spec.AppDir, outputDir)
 	resMap, err := EvaluateKustomizeManifest(path.Join(kustomizeDir, app.Name))
 	if err != nil {
@@ -170,38 +298,19 @@ func (kustomize *kustomize) render(app kfconfig.Application) ([]byte, error) {
 		}
 	}
 
+	if err := transformResources(kustomize.kfDef, app, resMap, report); err != nil {
+		return nil, err
+	}
+	report.addRenderedKinds(resMap)
+
 	sortResourceByKind(resMap, utils.InstallOrder)
 
-	// check to set owner references for resources if installed through kubeflow operator
-	annotations := kustomize.kfDef.GetAnnotations()
-	setOperatorAnnotation := false
-	if setOperator, ok := annotations[strings.Join([]string{utils.KfDefAnnotation, utils.SetAnnotation}, "/")]; ok {
-		if setOperatorBool, err := strconv.ParseBool(setOperator); err == nil {
-			setOperatorAnnotation = setOperatorBool
-		}
-	}
+	labels, annotations := commonMetadata(kustomize.kfDef, app)
 
 	//TODO this should be streamed
 	var data []byte
-	if setOperatorAnnotation {
-		// retrieve the UID of the KfDef resource using dynamic client
-		config, _ := rest.InClusterConfig()
-		dyn, err := dynamic.NewForConfig(config)
-		if err != nil {
-			return nil, &kfapisv3.KfError{
-				Code:    int(kfapisv3.INTERNAL_ERROR),
-				Message: fmt.Sprintf("failed to create dynamic client: %v", err),
-			}
-		}
-		kfDefRes := schema.GroupVersionResource{Group: "kfdef.apps.kubeflow.org", Version: "v1", Resource: "kfdefs"}
-		instance, err := dyn.Resource(kfDefRes).Namespace(kustomize.kfDef.GetNamespace()).Get(context.TODO(), kustomize.kfDef.GetName(), metav1.GetOptions{})
-		if err != nil {
-			return nil, &kfapisv3.KfError{
-				Code:    int(kfapisv3.INTERNAL_ERROR),
-				Message: fmt.Sprintf("failed to get the KfDef object: %v", err),
-			}
-		}
-		data, err = GenerateYamlWithOperatorAnnotation(resMap, instance)
+	if owner != nil {
+		data, err = owner.generate(resMap, app.Name, labels, annotations)
 		if err != nil {
 			return nil, &kfapisv3.KfError{
 				Code:    int(kfapisv3.INTERNAL_ERROR),
@@ -209,6 +318,7 @@ func (kustomize *kustomize) render(app kfconfig.Application) ([]byte, error) {
 			}
 		}
 	} else {
+		setResourcesCommonMetadata(resMap, labels, annotations)
 		data, err = resMap.AsYaml()
 		if err != nil {
 			return nil, &kfapisv3.KfError{
@@ -222,6 +332,10 @@ func (kustomize *kustomize) render(app kfconfig.Application) ([]byte, error) {
 
 // Dump prints the kustomize generated resources to stdout
 func (kustomize *kustomize) Dump(resources kftypesv3.ResourceEnum) error {
+	owner, err := kustomize.newOperatorOwner()
+	if err != nil {
+		return err
+	}
 
 	applications := make(map[string]bool)
 	for _, app := range kustomize.kfDef.Spec.Applications {
@@ -231,7 +345,7 @@ func (kustomize *kustomize) Dump(resources kftypesv3.ResourceEnum) error {
 		}
 		applications[app.Name] = true
 
-		data, err := kustomize.render(app)
+		data, err := kustomize.render(app, &renderReport{}, owner)
 		if err != nil {
 			return err
 		}
@@ -266,16 +380,31 @@ func (kustomize *kustomize) Apply(resources kftypesv3.ResourceEnum) error {
 		}
 	}
 
+	owner, err := kustomize.newOperatorOwner()
+	if err != nil {
+		return err
+	}
+
 	applications := make(map[string]bool)
+	report := &renderReport{}
+	selected := kustomize.selectedApplications()
+	if selected != nil {
+		// The kinds rendered for the other applications at the last apply are kept.
+		report.renderedKinds = append(report.renderedKinds, kustomize.kfDef.Status.RenderedKinds...)
+	}
 	for _, app := range kustomize.kfDef.Spec.Applications {
 		if applications[app.Name] == true {
 			// if the application name already
 			continue
 		}
 		applications[app.Name] = true
+		if !applicationSelected(selected, app.Name) {
+			log.Infof("Skipping application %v, which isn't selected", app.Name)
+			continue
+		}
 
 		log.Infof("Deploying application %v", app.Name)
-		data, err := kustomize.render(app)
+		data, err := kustomize.render(app, report, owner)
 		if err != nil {
 			return err
 		}
@@ -299,8 +428,26 @@ func (kustomize *kustomize) Apply(resources kftypesv3.ResourceEnum) error {
 			log.Errorf("Permanently failed applying application %v: %v", app.Name, err)
 			return err
 		}
+		if len(report.secretCopies) > 0 {
+			if err := kustomize.copySecrets(report.secretCopies); err != nil {
+				return err
+			}
+			report.secretCopies = nil
+		}
+		if kustomize.annotationEnabled(utils.SetAnnotation) {
+			if err := kustomize.prune(app.Name, data); err != nil {
+				return err
+			}
+		}
 		log.Infof("Successfully applied application %v", app.Name)
 	}
+	// The report only covers the selected applications, so the conditions are left as they were
+	// set by the last apply of all of them.
+	if selected == nil {
+		setReportCondition(kustomize.kfDef, kfconfig.RenderWarnings, "RenderWarnings", report.finish(kustomize.kfDef))
+		setReportCondition(kustomize.kfDef, kfconfig.PodSecurityHardened, "WorkloadsHardened", report.hardened)
+	}
+	kustomize.kfDef.Status.RenderedKinds = report.renderedKinds
 
 	// Default user namespace when multi-tenancy enabled
 	defaultProfileNamespace := kftypesv3.EmailToDefaultName(kustomize.kfDef.Spec.Email)
@@ -434,42 +581,85 @@ func (kustomize *kustomize) Delete(resources kftypesv3.ResourceEnum) error {
 	// Delete in reverse application order
 	kustomizeDir := path.Join(kustomize.kfDef.Spec.AppDir, outputDir)
 	errList := []error{}
+	kinds := []schema.GroupVersionKind{}
 	for idx := range kustomize.kfDef.Spec.Applications {
 		app := &kustomize.kfDef.Spec.Applications[len(kustomize.kfDef.Spec.Applications)-1-idx]
 		log.Infof("Deleting application %v", app.Name)
-		resMap, err := EvaluateKustomizeManifest(path.Join(kustomizeDir, app.Name))
-		if err != nil {
-			log.Errorf("Error evaluating kustomization manifest for %v: %v", app.Name, err)
-			return &kfapisv3.KfError{
-				Code:    int(kfapisv3.INTERNAL_ERROR),
-				Message: fmt.Sprintf("error evaluating kustomization manifest for %v: %v", app.Name, err),
+		// The package of an application is missing when only other applications were rendered by
+		// the last reconcile. The operator still deletes its resources by their ownership labels.
+		var yamlBytes []byte
+		var resources [][]byte
+		appDir := path.Join(kustomizeDir, app.Name)
+		if _, err := os.Stat(appDir); os.IsNotExist(err) {
+			log.Warnf("Package of application %v isn't rendered in %v", app.Name, kustomizeDir)
+		} else {
+			resMap, err := EvaluateKustomizeManifest(appDir)
+			if err != nil {
+				log.Errorf("Error evaluating kustomization manifest for %v: %v", app.Name, err)
+				return &kfapisv3.KfError{
+					Code:    int(kfapisv3.INTERNAL_ERROR),
+					Message: fmt.Sprintf("error evaluating kustomization manifest for %v: %v", app.Name, err),
+				}
 			}
-		}
 
-		// Sort resources by kind to make sure we don't experience namespace terminating hanging.
-		sortResourceByKind(resMap, utils.UninstallOrder)
+			// Sort resources by kind to make sure we don't experience namespace terminating hanging.
+			sortResourceByKind(resMap, utils.UninstallOrder)
 
-		yamlBytes, err := resMap.AsYaml()
-		if err != nil {
-			return &kfapisv3.KfError{
-				Code:    int(kfapisv3.INTERNAL_ERROR),
-				Message: fmt.Sprintf("error evaluating kustomization manifest for %v: %v", app.Name, err),
+			yamlBytes, err = resMap.AsYaml()
+			if err != nil {
+				return &kfapisv3.KfError{
+					Code:    int(kfapisv3.INTERNAL_ERROR),
+					Message: fmt.Sprintf("error evaluating kustomization manifest for %v: %v", app.Name, err),
+				}
+			}
+			resources, err = utils.SplitYAML(yamlBytes)
+			if err != nil {
+				return &kfapisv3.KfError{
+					Code:    int(kfapisv3.INTERNAL_ERROR),
+					Message: fmt.Sprintf("error splitting yaml: %v", err),
+				}
 			}
 		}
-		resources, err := utils.SplitYAML(yamlBytes)
-		if err != nil {
-			return &kfapisv3.KfError{
-				Code:    int(kfapisv3.INTERNAL_ERROR),
-				Message: fmt.Sprintf("error splitting yaml: %v", err),
+		// The operator deletes the resources by their ownership labels, so those of the kinds
+		// rendered at the last apply are deleted too
+		if byOperator {
+			labels := utils.OwnershipLabels(kustomize.kfDef.Name, kustomize.kfDef.Namespace, app.Name)
+			err := deleteApplication(kubeclient, app.Name, yamlBytes, kustomize.previousKinds(), labels,
+				kustomize.kfDef.Namespace, 5*time.Minute)
+			if err != nil {
+				errList = append(errList, err)
+				log.Warn(err)
+			}
+		} else {
+			for _, r := range resources {
+				err := utils.DeleteResource(r, kubeclient, 5*time.Minute, byOperator)
+				if err != nil {
+					msg := fmt.Sprintf("error evaluating kustomization manifest for %v: %v", app.Name, err)
+					errList = append(errList, errors.New(msg))
+					log.Warn(msg)
+				}
 			}
 		}
-		for _, r := range resources {
-			err := utils.DeleteResource(r, kubeclient, 5*time.Minute, byOperator)
+		if byOperator {
+			appKinds, _, err := renderedObjects(yamlBytes, kustomize.kfDef.Namespace)
 			if err != nil {
-				msg := fmt.Sprintf("error evaluating kustomization manifest for %v: %v", app.Name, err)
-				errList = append(errList, errors.New(msg))
-				log.Warn(msg)
+				return err
 			}
+			kinds = appendKinds(kinds, appKinds)
+		}
+	}
+
+	// Delete what is left of the resources labeled as managed for the KfDef, e.g. resources of
+	// applications which were removed from it, and the image pull secrets copied into the
+	// namespaces of the applications
+	if byOperator {
+		kinds = appendKinds(kinds, kustomize.previousKinds())
+		kinds = appendKinds(kinds, []schema.GroupVersionKind{{Version: "v1", Kind: "Secret"}})
+		labels := utils.OwnershipLabels(kustomize.kfDef.Name, kustomize.kfDef.Namespace, "")
+		if _, err := utils.DeleteOwnedResources(context.TODO(), kubeclient, kinds, labels, nil); err != nil {
+			msg := fmt.Sprintf("error deleting the resources of KfDef %v: %v", kustomize.kfDef.Name, err)
+			errList = append(errList, errors.New(msg))
+			log.Warn(msg)
 		}
 	}
 
@@ -575,24 +765,18 @@ func (kustomize *kustomize) Generate(resources kftypesv3.ResourceEnum) error {
 			return errors.WithStack(fmt.Errorf("Repo %v not listed in KfDef.Status; ", kftypesv3.ManifestsRepoName))
 		}
 
-		// determine whether we are using the new pattern of using kustomize to build stacks.
-		// hasStack := kustomize.kfDef.UsingStacks()
+		var unknownParams []string
+		selected := kustomize.selectedApplications()
 		for _, app := range kustomize.kfDef.Spec.Applications {
-			log.Infof("Processing application: %v", app.Name)
-
-			if app.KustomizeConfig == nil {
-				err := fmt.Errorf("application %v is missing KustomizeConfig", app.Name)
-				log.Errorf("%v", err)
-				return &kfapisv3.KfError{
-					Code:    int(kfapisv3.INTERNAL_ERROR),
-					Message: err.Error(),
-				}
+			if !applicationSelected(selected, app.Name) {
+				continue
 			}
+			log.Infof("Processing application: %v", app.Name)
 
-			repoName := app.KustomizeConfig.RepoRef.Name
-			repoCache, ok := kustomize.kfDef.GetRepoCache(repoName)
+			name, renderer, ok := renderers.ForApplication(app)
 			if !ok {
-				err := fmt.Errorf("application %v refers to repo %v which wasn't found in KfDef.Status.ReposCache", app.Name, repoName)
+				err := fmt.Errorf("application %v is missing the config of one of the sources %v",
+					app.Name, renderers.Names())
 				log.Errorf("%v", err)
 				return &kfapisv3.KfError{
 					Code:    int(kfapisv3.INTERNAL_ERROR),
@@ -600,51 +784,36 @@ func (kustomize *kustomize) Generate(resources kftypesv3.ResourceEnum) error {
 				}
 			}
 
-			appPath := path.Join(repoCache.LocalPath, app.KustomizeConfig.RepoRef.Path)
-
-			if kustomize.kfDef.UsingStacks() {
-
-				if filepath.IsAbs(appPath) {
-					// The appPath needs to be a relative path because we use it as a resource location in the kustomize
-					// file
-					appDir, err := filepath.Abs(kustomize.kfDef.Spec.AppDir)
-
-					if err != nil {
-						errors.WithStack(fmt.Errorf("There was a problem computing absolute path of %v; error; %v ", kustomize.kfDef.Spec.AppDir, err))
-					}
-					relPath, err := filepath.Rel(appDir, appPath)
-					if err != nil {
-						errors.WithStack(fmt.Errorf("There was a problem computing filePath.Rel(%v, %v); error; %v ", appDir, appPath, err))
-					}
-
-					appPath = relPath
-				}
-				// We handle generating the kustomize dir for application stacks differently.
-				stackAppDir := path.Join(kustomizeDir, app.Name)
-
-				// Path to the stack inside the cache.
-				stacksCacheDir := filepath.Join("../..", appPath)
-				if _, err := createStackAppKustomization(stackAppDir, stacksCacheDir); err != nil {
-					return errors.WithStack(fmt.Errorf("There was a problem building the kustomize app for the Kubeflow application stack; %v ", err))
-				}
-			} else {
-				// TODO(jlewi): This code path should eventually go away once we are fully migrated to the use
-				// of stacks.
-				// Copy the component to kustomizeDir
-				if err := copy.Copy(appPath, path.Join(kustomizeDir, app.Name)); err != nil {
-					return &kfapisv3.KfError{
-						Code:    int(kfapisv3.INTERNAL_ERROR),
-						Message: fmt.Sprintf("couldn't copy application %s: %v", app.Name, err),
-					}
+			if app.KustomizeConfig != nil && !kustomize.kfDef.UsingStacks() {
+				msg, err := unknownParametersMessage(kustomize.kfDef, app)
+				if err != nil {
+					return err
 				}
-				if err := GenerateKustomizationFile(kustomize.kfDef, kustomizeDir, app.Name,
-					app.KustomizeConfig.Overlays, app.KustomizeConfig.Parameters); err != nil {
-					return &kfapisv3.KfError{
-						Code:    int(kfapisv3.INTERNAL_ERROR),
-						Message: fmt.Sprintf("couldn't generate kustomization file for component %s: %v", app.Name, err),
+				if msg != "" {
+					if kustomize.kfDef.Spec.StrictParameters {
+						return &kfapisv3.KfError{
+							Code:    int(kfapisv3.INVALID_ARGUMENT),
+							Message: msg,
+						}
 					}
+					log.Warnf("%v", msg)
+					unknownParams = append(unknownParams, msg)
 				}
 			}
+
+			// The resources rendered for the application are written as a kustomize package, which is
+			// evaluated when the application is applied or deleted.
+			log.Infof("Rendering application %v with renderer %v", app.Name, name)
+			objects, err := renderer.Render(kustomize.kfDef, app)
+			if err != nil {
+				return err
+			}
+			if err := writeResourcesPackage(path.Join(kustomizeDir, app.Name), app.Name, objects); err != nil {
+				return err
+			}
+		}
+		if selected == nil {
+			setReportCondition(kustomize.kfDef, kfconfig.UnknownParameters, "ParametersNotDeclared", unknownParams)
 		}
 		return nil
 	}
@@ -1091,6 +1260,40 @@ func MergeKustomization(compDir string, targetDir string, kfDef *kfconfig.KfConf
 	return nil
 }
 
+// ValidateOverlays returns an INVALID_ARGUMENT error naming the application, the missing overlays
+// and the ones which exist if any of the overlays isn't a directory of the component at compDir.
+// Retrying won't help, so the error is permanent. It only reads compDir, so it can also be used
+// to check the overlays of an application before it's applied.
+func ValidateOverlays(appName string, compDir string, overlays []string) error {
+	var missing []string
+	for _, overlay := range overlays {
+		if info, err := os.Stat(path.Join(compDir, "overlays", overlay)); err != nil || !info.IsDir() {
+			missing = append(missing, overlay)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	var existing []string
+	if files, err := ioutil.ReadDir(path.Join(compDir, "overlays")); err == nil {
+		for _, f := range files {
+			if f.IsDir() {
+				existing = append(existing, f.Name())
+			}
+		}
+	}
+	msg := fmt.Sprintf("application %v has no overlay %v", appName, strings.Join(missing, ", "))
+	if len(existing) == 0 {
+		msg += "; it has no overlays"
+	} else {
+		msg += fmt.Sprintf("; its overlays are %v", strings.Join(existing, ", "))
+	}
+	return &kfapisv3.KfError{
+		Code:    int(kfapisv3.INVALID_ARGUMENT),
+		Message: msg,
+	}
+}
+
 // MergeKustomizations will merge base and all overlay kustomization files into
 // a single kustomization file
 func MergeKustomizations(kfDef *kfconfig.KfConfig, compDir string, overlayParams []string, params []kfconfig.NameValue) (*types.Kustomization, error) {
@@ -1113,6 +1316,10 @@ func MergeKustomizations(kfDef *kfconfig.KfConfig, compDir string, overlayParams
 		SecretGenerator:       make([]types.SecretArgs, 0),
 		Configurations:        make([]string, 0),
 	}
+	// The component directory is named after the application.
+	if err := ValidateOverlays(path.Base(compDir), compDir, overlayParams); err != nil {
+		return nil, err
+	}
 	baseDir := path.Join(compDir, "base")
 	base := GetKustomization(baseDir)
 	if base == nil {
@@ -1140,19 +1347,12 @@ func MergeKustomizations(kfDef *kfconfig.KfConfig, compDir string, overlayParams
 	}
 	for _, overlayParam := range overlayParams {
 		overlayDir := path.Join(compDir, "overlays", overlayParam)
-		if _, err := os.Stat(overlayDir); err == nil {
-			err := MergeKustomization(compDir, overlayDir, kfDef, params, kustomization,
-				GetKustomization(overlayDir), kustomizationMaps)
-			if err != nil {
-				return nil, &kfapisv3.KfError{
-					Code:    int(kfapisv3.INTERNAL_ERROR),
-					Message: fmt.Sprintf("error merging kustomization at %v: %v", overlayDir, err),
-				}
-			}
-		} else {
+		err := MergeKustomization(compDir, overlayDir, kfDef, params, kustomization,
+			GetKustomization(overlayDir), kustomizationMaps)
+		if err != nil {
 			return nil, &kfapisv3.KfError{
 				Code:    int(kfapisv3.INTERNAL_ERROR),
-				Message: fmt.Sprintf("no overlay %v for component at %v: %v", overlayParam, compDir, err),
+				Message: fmt.Sprintf("error merging kustomization at %v: %v", overlayDir, err),
 			}
 		}
 	}
@@ -1207,6 +1407,67 @@ func MergeKustomizations(kfDef *kfconfig.KfConfig, compDir string, overlayParams
 	return kustomization, nil
 }
 
+// UnknownParameters returns the names of the params which aren't declared in the params.env of
+// the base or any of the overlays of the component at compDir, i.e. the ones MergeKustomization
+// would ignore. The namespace parameter is always known as it sets the namespace of the kustomization.
+func UnknownParameters(compDir string, overlays []string, params []kfconfig.NameValue) ([]string, error) {
+	declared := map[string]bool{"namespace": true}
+	for _, dir := range paramDirs(compDir, overlays) {
+		paramFile := filepath.Join(dir, kftypesv3.KustomizationParamFile)
+		if _, err := os.Stat(paramFile); err != nil {
+			continue
+		}
+		lines, err := readLines(paramFile)
+		if err != nil {
+			return nil, &kfapisv3.KfError{
+				Code:    int(kfapisv3.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("could not open %v: %v", paramFile, err),
+			}
+		}
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			declared[strings.Split(line, "=")[0]] = true
+		}
+	}
+	unknown := []string{}
+	for _, nv := range params {
+		if !declared[nv.Name] {
+			unknown = append(unknown, nv.Name)
+		}
+	}
+	return unknown, nil
+}
+
+// paramDirs returns the directories of the component at compDir whose params.env are merged
+// by MergeKustomizations, i.e. its base and overlays. There are none if it has no base.
+func paramDirs(compDir string, overlays []string) []string {
+	baseDir := path.Join(compDir, "base")
+	if GetKustomization(baseDir) == nil {
+		return nil
+	}
+	dirs := []string{baseDir}
+	for _, overlay := range overlays {
+		dirs = append(dirs, path.Join(compDir, "overlays", overlay))
+	}
+	return dirs
+}
+
+// setReportCondition records what the plugin reports as a condition of the given type, e.g. the
+// applications setting unknown parameters as the UnknownParameters condition. It's reset once
+// there's nothing to report.
+func setReportCondition(kfDef *kfconfig.KfConfig, condType kfconfig.ConditionType, reason string, msgs []string) {
+	if len(msgs) > 0 {
+		kfDef.SetCondition(condType, v1.ConditionTrue, reason, strings.Join(msgs, "; "))
+		return
+	}
+	if _, err := kfDef.GetCondition(condType); err == nil {
+		kfDef.SetCondition(condType, v1.ConditionFalse, "", "Nothing to report")
+	}
+}
+
 // GenerateKustomizationFile will create a kustomization.yaml
 // It will parse a args structure that provides mixin or multiple overlays to be merged with the base kustomization file
 // for example
@@ -1278,6 +1539,27 @@ func GenerateKustomizationFile(kfDef *kfconfig.KfConfig, root string,
 
 // EvaluateKustomizeManifest evaluates the kustomize dir compDir, and returns the resources.
 func EvaluateKustomizeManifest(compDir string) (resmap.ResMap, error) {
+	allResources, err := buildKustomizeManifest(compDir)
+	if err != nil {
+		return nil, err
+	}
+	customPlugin := &UpdateResourcesPlugin{
+		rmf:        resmap.NewFactory(resource.NewFactory(kunstruct.NewKunstructuredFactoryImpl()), transformer.NewFactoryImpl()),
+		c:          nil,
+		ObjectMeta: types.ObjectMeta{},
+		Spec:       Spec{},
+	}
+	err = customPlugin.Transform(allResources)
+	if err != nil {
+		log.Warn("Error during custom transform", err)
+		return nil, err
+	}
+	return allResources, nil
+}
+
+// buildKustomizeManifest builds the kustomize dir compDir, without updating its resources from
+// the ones in the cluster like EvaluateKustomizeManifest.
+func buildKustomizeManifest(compDir string) (resmap.ResMap, error) {
 	fsys := fs.MakeFsOnDisk()
 	// We don't enforce the security check because our kustomize packages are such that kustomization.yaml
 	// files may refer to patches and resources that are not in the current directory or below them.
@@ -1308,18 +1590,6 @@ func EvaluateKustomizeManifest(compDir string) (resmap.ResMap, error) {
 		log.Warn("Error during transform", err)
 		return nil, err
 	}
-	customPlugin := &UpdateResourcesPlugin{
-		rmf:        rf,
-		ldr:        ldr,
-		c:          nil,
-		ObjectMeta: types.ObjectMeta{},
-		Spec:       Spec{},
-	}
-	err = customPlugin.Transform(allResources)
-	if err != nil {
-		log.Warn("Error during custom transform", err)
-		return nil, err
-	}
 	return allResources, nil
 }
 
@@ -1400,10 +1670,15 @@ func CreateKustomizationMaps() map[MapType]map[string]bool {
 	}
 }
 
-// GenerateYamlWithOperatorAnnotation adds operator info to the annotation to every resource
+// GenerateYamlWithOperatorAnnotation adds operator info to the annotation and the ownership labels
+// of the application to every resource, along with the common labels and annotations, and sets
+// the KfDef as the controller of the namespaced resources in its namespace, as told by the mapper.
+// Namespaces which already exist without being annotated for the KfDef, as told by the namespaces
+// client, and the profiles CRD are left alone.
 // some code copied from ResMap.AsYaml() func
-func GenerateYamlWithOperatorAnnotation(resMap resmap.ResMap, instance *unstructured.Unstructured) ([]byte, error) {
-	addAnnotation := true
+func GenerateYamlWithOperatorAnnotation(resMap resmap.ResMap, instance *unstructured.Unstructured, mapper meta.RESTMapper,
+	namespaces corev1.NamespaceInterface, app string, labels map[string]string, annotations map[string]string) ([]byte, error) {
+	ownershipLabels := utils.OwnershipLabels(instance.GetName(), instance.GetNamespace(), app)
 	firstObj := true
 	var b []byte
 	buf := bytes.NewBuffer(b)
@@ -1422,26 +1697,24 @@ func GenerateYamlWithOperatorAnnotation(resMap resmap.ResMap, instance *unstruct
 			anns = map[string]string{}
 		}
 		kfdefAnn := strings.Join([]string{utils.KfDefAnnotation, utils.KfDefInstance}, "/")
-		kfdefCr := strings.Join([]string{instance.GetName(), instance.GetNamespace()}, ".")
+		kfdefCr := utils.KfDefInstanceKey(instance.GetName(), instance.GetNamespace())
 
-		if m.GetKind() == "Namespace" {
-			config, _ := rest.InClusterConfig()
-			corev1client, err := corev1.NewForConfig(config)
-			if err != nil {
-				return nil, &kfapisv3.KfError{
-					Code:    int(kfapisv3.INTERNAL_ERROR),
-					Message: fmt.Sprintf("failed to create corev1 client: %v", err),
-				}
-			}
-			_, err = corev1client.Namespaces().Get(context.TODO(), m.GetName(), metav1.GetOptions{})
+		// whether the resource is managed for the KfDef is decided for each resource
+		addAnnotation := true
+		if m.GetKind() == "Namespace" && namespaces != nil {
+			existing, err := namespaces.Get(context.TODO(), m.GetName(), metav1.GetOptions{})
 			if err == nil {
 				log.Infof("Namespace %v already exists.", m.GetName())
 
-				_, found := anns[kfdefAnn]
-				if !found || anns[kfdefAnn] != kfdefCr {
+				if existing.GetAnnotations()[kfdefAnn] != kfdefCr {
 					// if the namespace is not created by this operator, should not append the annotation
 					addAnnotation = false
 				}
+			} else if !apierrors.IsNotFound(err) {
+				return nil, &kfapisv3.KfError{
+					Code:    int(kfapisv3.INTERNAL_ERROR),
+					Message: fmt.Sprintf("failed to get namespace %v: %v", m.GetName(), err),
+				}
 			}
 		} else if m.GetKind() == "CustomResourceDefinition" && m.GetName() == "profiles.kubeflow.org" {
 			// profiles will contain user info and data, should not remove during uninstall
@@ -1451,6 +1724,18 @@ func GenerateYamlWithOperatorAnnotation(resMap resmap.ResMap, instance *unstruct
 		if addAnnotation {
 			anns[kfdefAnn] = kfdefCr
 			m.SetAnnotations(anns)
+			setCommonMetadata(m, labels, annotations)
+			resLabels := m.GetLabels()
+			if resLabels == nil {
+				resLabels = map[string]string{}
+			}
+			for k, v := range ownershipLabels {
+				resLabels[k] = v
+			}
+			m.SetLabels(resLabels)
+			if _, err := setControllerReference(m, instance, mapper); err != nil {
+				return nil, err
+			}
 		}
 		out, err := yaml.Marshal(m)
 		if err != nil {
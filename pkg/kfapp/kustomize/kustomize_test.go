@@ -11,10 +11,17 @@ import (
 	"strings"
 	"testing"
 
+	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/utils"
 	"github.com/otiai10/copy"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 // This test tests that GenerateKustomizationFile will produce correct kustomization.yaml
@@ -88,6 +95,69 @@ func TestGenerateKustomizationFile(t *testing.T) {
 	}
 }
 
+func TestValidateOverlays(t *testing.T) {
+	packageDir := "testdata/kustomizeExample/metadata"
+	if err := ValidateOverlays("metadata", packageDir, []string{"istio", "db"}); err != nil {
+		t.Errorf("Expected the overlays to be valid; got %v", err)
+	}
+
+	err := ValidateOverlays("metadata", packageDir, []string{"istio", "postgres"})
+	kfErr, ok := err.(*kfapis.KfError)
+	if !ok || kfErr.Code != int(kfapis.INVALID_ARGUMENT) {
+		t.Fatalf("Expected an INVALID_ARGUMENT error; got %v", err)
+	}
+	expected := "application metadata has no overlay postgres; its overlays are " +
+		"application, db, external-mysql, google-cloudsql, ibm-storage-config, istio"
+	if kfErr.Message != expected {
+		t.Errorf("Unexpected message; got %q, want %q", kfErr.Message, expected)
+	}
+
+	// Overlays of a package without a base used to be skipped.
+	testDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(testDir)
+	compDir := path.Join(testDir, "dashboard")
+	if err := os.MkdirAll(compDir, os.ModePerm); err != nil {
+		t.Fatalf("Failed to create %v: %v", compDir, err)
+	}
+	_, err = MergeKustomizations(&kfconfig.KfConfig{}, compDir, []string{"odh"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "application dashboard has no overlay odh; it has no overlays") {
+		t.Errorf("Expected a missing overlay error; got %v", err)
+	}
+}
+
+func TestUnknownParameters(t *testing.T) {
+	packageDir := "testdata/kustomizeExample/metadata"
+	params := []kfconfig.NameValue{
+		{Name: "namespace", Value: "opendatahub"},
+		{Name: "uiClusterDomain", Value: "cluster.example.com"},
+		{Name: "MYSQL_PORT", Value: "3307"},
+		// Only declared by the external-mysql overlay, which isn't selected.
+		{Name: "MYSQL_HOST", Value: "mysql.example.com"},
+		{Name: "MYSQL_PROT", Value: "3307"},
+	}
+	unknown, err := UnknownParameters(packageDir, []string{"istio", "application", "db"}, params)
+	if err != nil {
+		t.Fatalf("Failed to get unknown parameters: %v", err)
+	}
+	if diff := cmp.Diff([]string{"MYSQL_HOST", "MYSQL_PROT"}, unknown); diff != "" {
+		t.Errorf("Unknown parameters are different from expected. (-want, +got):\n%s", diff)
+	}
+
+	kfDef := &kfconfig.KfConfig{}
+	setReportCondition(kfDef, kfconfig.UnknownParameters, "ParametersNotDeclared", []string{"application metadata sets parameters MYSQL_PROT"})
+	cond, err := kfDef.GetCondition(kfconfig.UnknownParameters)
+	if err != nil || cond.Status != "True" {
+		t.Fatalf("Expected the UnknownParameters condition to be true; got %v, %v", cond, err)
+	}
+	setReportCondition(kfDef, kfconfig.UnknownParameters, "ParametersNotDeclared", nil)
+	if cond, _ := kfDef.GetCondition(kfconfig.UnknownParameters); cond.Status != "False" {
+		t.Errorf("Expected the UnknownParameters condition to be reset; got %v", cond)
+	}
+}
+
 // TestGenerateYamlWithOperatorAnnotation
 func TestGenerateYamlWithOperatorAnnotation(t *testing.T) {
 	type testCase struct {
@@ -107,11 +177,11 @@ func TestGenerateYamlWithOperatorAnnotation(t *testing.T) {
 	instance.SetNamespace("kubeflow")
 
 	for _, c := range testCases {
-		resMap, err := EvaluateKustomizeManifest(c.appDir)
+		resMap, err := buildKustomizeManifest(c.appDir)
 		if err != nil {
 			t.Fatalf("Failed to evaluate manifest. Error: %v.", err)
 		}
-		actual, err := GenerateYamlWithOperatorAnnotation(resMap, instance)
+		actual, err := GenerateYamlWithOperatorAnnotation(resMap, instance, nil, nil, "", nil, nil)
 		if err != nil {
 			t.Fatalf("Failed to add owner reference. Error: %v.", err)
 		}
@@ -125,6 +195,43 @@ func TestGenerateYamlWithOperatorAnnotation(t *testing.T) {
 	}
 }
 
+// An existing namespace the KfDef doesn't manage is left alone, but not the resources following it.
+func TestGenerateYamlWithOperatorAnnotation_ExistingNamespace(t *testing.T) {
+	resMap := newTestResMap(t, "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: kubeflow\n---\n"+testDeployment)
+	instance := &unstructured.Unstructured{}
+	instance.SetAPIVersion("kfdef.apps.kubeflow.org/v1")
+	instance.SetKind("KfDef")
+	instance.SetName("operator")
+	instance.SetNamespace("odh-dashboard")
+	instance.SetUID("kfdef-uid")
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	namespaces := fake.NewSimpleClientset(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kubeflow"}}).CoreV1().Namespaces()
+
+	data, err := GenerateYamlWithOperatorAnnotation(resMap, instance, mapper, namespaces, "dashboard", nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to add operator annotation. Error: %v.", err)
+	}
+	objects, err := renderers.DecodeObjects(data)
+	if err != nil || len(objects) != 2 {
+		t.Fatalf("Failed to decode the resources; got %v, %v", objects, err)
+	}
+	namespace, deployment := objects[0], objects[1]
+	if len(namespace.GetAnnotations()) > 0 || len(namespace.GetLabels()) > 0 {
+		t.Errorf("Expected the existing namespace to be left alone; got %v", namespace)
+	}
+	if actual := deployment.GetAnnotations()["kfctl.kubeflow.io/kfdef-instance"]; actual != "operator.odh-dashboard" {
+		t.Errorf("Unexpected kfdef-instance annotation of the deployment; got %v", actual)
+	}
+	if actual := deployment.GetLabels()[utils.KfDefApplicationLabel]; actual != "dashboard" {
+		t.Errorf("Unexpected application label of the deployment; got %v", actual)
+	}
+	if refs := deployment.GetOwnerReferences(); len(refs) != 1 || refs[0].UID != "kfdef-uid" {
+		t.Errorf("Expected the KfDef to control the deployment; got %v", refs)
+	}
+}
+
 func TestCreateStackAppKustomization(t *testing.T) {
 	type testCase struct {
 		Name     string
@@ -0,0 +1,95 @@
+package kustomize
+
+import (
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/v3/pkg/resmap"
+)
+
+// commonMetadata returns the common labels and annotations of the application: the ones of the
+// KfDef, replaced by the ones of the application with the same key.
+func commonMetadata(config *kfconfig.KfConfig, app kfconfig.Application) (map[string]string, map[string]string) {
+	merge := func(maps ...map[string]string) map[string]string {
+		merged := map[string]string{}
+		for _, m := range maps {
+			for k, v := range m {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+	return merge(config.Spec.CommonLabels, app.CommonLabels), merge(config.Spec.CommonAnnotations, app.CommonAnnotations)
+}
+
+// workloadSelector returns the labels the selector of a workload matches, which its pod template
+// has to keep.
+func workloadSelector(m *unstructured.Unstructured) map[string]string {
+	if selector, found, _ := unstructured.NestedStringMap(m.Object, "spec", "selector", "matchLabels"); found {
+		return selector
+	}
+	// ReplicationControllers and DeploymentConfigs select their pods by a map of labels.
+	selector, _, _ := unstructured.NestedStringMap(m.Object, "spec", "selector")
+	return selector
+}
+
+// setCommonMetadata adds the common labels and annotations to the resource, and the labels to
+// the pod template of a workload. The selectors are left alone, since they're immutable for most
+// workloads, and so are the pod template labels the selector matches with another value.
+// Jobs keep their pod template too, since it's immutable.
+func setCommonMetadata(m *unstructured.Unstructured, labels map[string]string, annotations map[string]string) {
+	if len(labels) > 0 {
+		resourceLabels := m.GetLabels()
+		if resourceLabels == nil {
+			resourceLabels = map[string]string{}
+		}
+		for k, v := range labels {
+			resourceLabels[k] = v
+		}
+		m.SetLabels(resourceLabels)
+	}
+	if len(annotations) > 0 {
+		resourceAnnotations := m.GetAnnotations()
+		if resourceAnnotations == nil {
+			resourceAnnotations = map[string]string{}
+		}
+		for k, v := range annotations {
+			resourceAnnotations[k] = v
+		}
+		m.SetAnnotations(resourceAnnotations)
+	}
+
+	fields, ok := podSpecFields[m.GetKind()]
+	if !ok || len(labels) == 0 || len(fields) < 2 || m.GetKind() == "Job" {
+		return
+	}
+	templateLabelsFields := append(append([]string{}, fields[:len(fields)-1]...), "metadata", "labels")
+	templateLabels, _, err := unstructured.NestedStringMap(m.Object, templateLabelsFields...)
+	if err != nil {
+		return
+	}
+	if templateLabels == nil {
+		templateLabels = map[string]string{}
+	}
+	selector := workloadSelector(m)
+	for k, v := range labels {
+		if selected, ok := selector[k]; ok && selected != v {
+			log.Warnf("Not setting label %v=%v on the pods of %v %v, its selector matches %v=%v", k, v, m.GetKind(), m.GetName(), k, selected)
+			continue
+		}
+		templateLabels[k] = v
+	}
+	unstructured.SetNestedStringMap(m.Object, templateLabels, templateLabelsFields...)
+}
+
+// setResourcesCommonMetadata adds the common labels and annotations to the resources.
+func setResourcesCommonMetadata(resMap resmap.ResMap, labels map[string]string, annotations map[string]string) {
+	if len(labels) == 0 && len(annotations) == 0 {
+		return
+	}
+	for _, r := range resMap.Resources() {
+		m := &unstructured.Unstructured{Object: r.Map()}
+		setCommonMetadata(m, labels, annotations)
+		r.SetMap(m.Object)
+	}
+}
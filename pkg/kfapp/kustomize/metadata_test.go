@@ -0,0 +1,64 @@
+package kustomize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCommonMetadata(t *testing.T) {
+	config := &kfconfig.KfConfig{
+		Spec: kfconfig.KfConfigSpec{
+			CommonLabels:      map[string]string{"cost-center": "1234", "team": "platform"},
+			CommonAnnotations: map[string]string{"example.com/owner": "platform"},
+		},
+	}
+	app := kfconfig.Application{
+		Name:         "dashboard",
+		CommonLabels: map[string]string{"team": "data-science"},
+	}
+	labels, annotations := commonMetadata(config, app)
+	expectedLabels := map[string]string{"cost-center": "1234", "team": "data-science"}
+	if !reflect.DeepEqual(labels, expectedLabels) {
+		t.Errorf("Unexpected labels; got %v; want %v", labels, expectedLabels)
+	}
+	expectedAnnotations := map[string]string{"example.com/owner": "platform"}
+	if !reflect.DeepEqual(annotations, expectedAnnotations) {
+		t.Errorf("Unexpected annotations; got %v; want %v", annotations, expectedAnnotations)
+	}
+}
+
+func TestSetResourcesCommonMetadata(t *testing.T) {
+	resMap := newTestResMap(t, testDeployment+"---\n"+testJob)
+	labels := map[string]string{"team": "data-science", "app": "odh"}
+	setResourcesCommonMetadata(resMap, labels, map[string]string{"example.com/owner": "platform"})
+
+	deployment := resourceMap(t, resMap, "Deployment")
+	expected := map[string]string{"team": "data-science", "app": "odh"}
+	if actual, _, _ := unstructured.NestedStringMap(deployment, "metadata", "labels"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected labels; got %v; want %v", actual, expected)
+	}
+	if actual, _, _ := unstructured.NestedString(deployment, "metadata", "annotations", "example.com/owner"); actual != "platform" {
+		t.Errorf("Unexpected annotation; got %v", actual)
+	}
+	// The selector is left alone, and so is the label of the pods it matches.
+	expected = map[string]string{"app": "dashboard"}
+	if actual, _, _ := unstructured.NestedStringMap(deployment, "spec", "selector", "matchLabels"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected selector; got %v; want %v", actual, expected)
+	}
+	expected = map[string]string{"team": "data-science", "app": "dashboard"}
+	if actual, _, _ := unstructured.NestedStringMap(deployment, "spec", "template", "metadata", "labels"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected pod template labels; got %v; want %v", actual, expected)
+	}
+
+	// The pod template of a Job is immutable, so only the Job itself is labeled.
+	job := resourceMap(t, resMap, "Job")
+	if actual, _, _ := unstructured.NestedStringMap(job, "metadata", "labels"); !reflect.DeepEqual(actual, labels) {
+		t.Errorf("Unexpected job labels; got %v; want %v", actual, labels)
+	}
+	if _, found, _ := unstructured.NestedMap(job, "spec", "template", "metadata"); found {
+		t.Errorf("Expected the job pod template to be left alone")
+	}
+}
@@ -0,0 +1,59 @@
+package kustomize
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// setControllerReference sets the KfDef as the controller of the resource if the resource is
+// namespaced and applied in the namespace of the KfDef, so it is garbage collected with the KfDef
+// and its changes are watched through its owner. Cluster-scoped and cross-namespace resources
+// can't be owned by the KfDef and are only tracked by the kfdef-instance annotation and the
+// ownership labels, as are resources whose kind isn't served yet, e.g. custom resources applied
+// along with their CRDs, or which are already controlled by another object.
+// It returns true if the owner reference is set.
+func setControllerReference(m *unstructured.Unstructured, instance *unstructured.Unstructured, mapper meta.RESTMapper) (bool, error) {
+	if mapper == nil {
+		return false, nil
+	}
+	if m.GetNamespace() != "" && m.GetNamespace() != instance.GetNamespace() {
+		return false, nil
+	}
+	gvk := m.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return false, nil
+	}
+
+	ref := metav1.OwnerReference{
+		APIVersion:         instance.GetAPIVersion(),
+		Kind:               instance.GetKind(),
+		Name:               instance.GetName(),
+		UID:                instance.GetUID(),
+		Controller:         boolPtr(true),
+		BlockOwnerDeletion: boolPtr(true),
+	}
+	refs := []metav1.OwnerReference{}
+	for _, existing := range m.GetOwnerReferences() {
+		if existing.UID == ref.UID {
+			continue
+		}
+		if existing.Controller != nil && *existing.Controller {
+			return false, nil
+		}
+		refs = append(refs, existing)
+	}
+	m.SetOwnerReferences(append(refs, ref))
+	return true, nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,133 @@
+package kustomize
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/restmapper"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+func TestSetControllerReference(t *testing.T) {
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	clusterRole := schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(configMap, meta.RESTScopeNamespace)
+	mapper.Add(clusterRole, meta.RESTScopeRoot)
+
+	instance := &unstructured.Unstructured{}
+	instance.SetAPIVersion("kfdef.apps.kubeflow.org/v1")
+	instance.SetKind("KfDef")
+	instance.SetName("opendatahub")
+	instance.SetNamespace("odh")
+	instance.SetUID(types.UID("kfdef-uid"))
+
+	newObject := func(gvk schema.GroupVersionKind, namespace string, owners ...metav1.OwnerReference) *unstructured.Unstructured {
+		m := &unstructured.Unstructured{}
+		m.SetGroupVersionKind(gvk)
+		m.SetName("dashboard")
+		m.SetNamespace(namespace)
+		m.SetOwnerReferences(owners)
+		return m
+	}
+	controller := true
+	otherController := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "other-uid", Controller: &controller}
+
+	tests := []struct {
+		name     string
+		object   *unstructured.Unstructured
+		expected bool
+	}{
+		{name: "same namespace", object: newObject(configMap, "odh"), expected: true},
+		{name: "default namespace", object: newObject(configMap, ""), expected: true},
+		{name: "other namespace", object: newObject(configMap, "other")},
+		{name: "cluster-scoped", object: newObject(clusterRole, "")},
+		{name: "kind not served", object: newObject(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Unknown"}, "odh")},
+		{name: "controlled by another object", object: newObject(configMap, "odh", otherController)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			set, err := setControllerReference(test.object, instance, mapper)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if set != test.expected {
+				t.Fatalf("expected owner reference set %v, got %v", test.expected, set)
+			}
+			owners := test.object.GetOwnerReferences()
+			if !set {
+				return
+			}
+			if len(owners) != 1 || owners[0].UID != "kfdef-uid" || owners[0].Kind != "KfDef" ||
+				owners[0].Controller == nil || !*owners[0].Controller {
+				t.Errorf("unexpected owner references %v", owners)
+			}
+			// setting it again doesn't duplicate it
+			if _, err := setControllerReference(test.object, instance, mapper); err != nil || len(test.object.GetOwnerReferences()) != 1 {
+				t.Errorf("unexpected owner references %v: %v", test.object.GetOwnerReferences(), err)
+			}
+		})
+	}
+}
+
+func TestOperatorOwner_NewKinds(t *testing.T) {
+	discovery := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Namespaced: true}}},
+	}}}
+	instance := &unstructured.Unstructured{}
+	instance.SetAPIVersion("kfdef.apps.kubeflow.org/v1")
+	instance.SetKind("KfDef")
+	instance.SetName("opendatahub")
+	instance.SetNamespace("odh")
+	instance.SetUID(types.UID("kfdef-uid"))
+	owner := &operatorOwner{
+		instance: instance,
+		mapper:   restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discovery)),
+	}
+
+	// the application applying the CRD
+	crd := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: odhapplications.dashboard.opendatahub.io
+`
+	if _, err := owner.generate(newTestResMap(t, crd), "dashboard-crds", nil, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !owner.stale {
+		t.Errorf("expected the kinds to be discovered again")
+	}
+
+	// the application rendering the custom resources, once the CRD is applied
+	discovery.Resources = append(discovery.Resources, &metav1.APIResourceList{
+		GroupVersion: "dashboard.opendatahub.io/v1",
+		APIResources: []metav1.APIResource{{Name: "odhapplications", Kind: "OdhApplication", Namespaced: true}},
+	})
+	odhApplication := `apiVersion: dashboard.opendatahub.io/v1
+kind: OdhApplication
+metadata:
+  name: jupyter
+  namespace: odh
+`
+	data, err := owner.generate(newTestResMap(t, odhApplication), "dashboard", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(data, obj); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if owners := obj.GetOwnerReferences(); len(owners) != 1 || owners[0].UID != "kfdef-uid" {
+		t.Errorf("unexpected owner references %v", owners)
+	}
+	if owner.stale {
+		t.Errorf("expected the kinds to be discovered")
+	}
+}
@@ -0,0 +1,171 @@
+package kustomize
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"sigs.k8s.io/kustomize/v3/k8sdeps/kunstruct"
+	"sigs.k8s.io/kustomize/v3/k8sdeps/transformer"
+	"sigs.k8s.io/kustomize/v3/pkg/gvk"
+	"sigs.k8s.io/kustomize/v3/pkg/resmap"
+	"sigs.k8s.io/kustomize/v3/pkg/resource"
+	"sigs.k8s.io/kustomize/v3/pkg/types"
+	"sigs.k8s.io/kustomize/v3/plugin/builtin"
+	"sigs.k8s.io/yaml"
+)
+
+// transformPatches applies the patches of the KfDef, then the ones of the application, to the
+// resources of the application. The patches of the application which don't match any of its
+// resources are reported, while the ones of the KfDef are counted in the report, since they
+// usually only target some of the applications.
+func transformPatches(config *kfconfig.KfConfig, app kfconfig.Application, resMap resmap.ResMap, report *renderReport) error {
+	for i, patch := range config.Spec.Patches {
+		matches, err := applyPatch(patch, resMap, fmt.Sprintf("patch %v of the KfDef", i))
+		if err != nil {
+			return err
+		}
+		if report.kfDefPatchMatches == nil {
+			report.kfDefPatchMatches = map[int]int{}
+		}
+		report.kfDefPatchMatches[i] += matches
+	}
+	for i, patch := range app.Patches {
+		matches, err := applyPatch(patch, resMap, fmt.Sprintf("patch %v of application %v", i, app.Name))
+		if err != nil {
+			return err
+		}
+		if matches == 0 {
+			report.warnf("patch %v of application %v (%v) doesn't match any resource", i, app.Name, describeTarget(patch.Target))
+		}
+	}
+	return nil
+}
+
+// applyPatch applies the strategic merge or JSON 6902 patch to the resources matching its target,
+// using the kustomize patch transform. It returns the number of resources patched.
+func applyPatch(patch kfconfig.Patch, resMap resmap.ResMap, desc string) (int, error) {
+	if patch.Target == nil {
+		return 0, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("%v has no target", desc),
+		}
+	}
+	for _, expr := range []string{patch.Target.Name, patch.Target.Namespace} {
+		if _, err := regexp.Compile(expr); err != nil {
+			return 0, &kfapisv3.KfError{
+				Code:    int(kfapisv3.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("%v has an invalid target: %v", desc, err),
+			}
+		}
+	}
+	selector := &types.Selector{
+		Gvk: gvk.Gvk{
+			Group:   patch.Target.Group,
+			Version: patch.Target.Version,
+			Kind:    patch.Target.Kind,
+		},
+		Namespace:     patch.Target.Namespace,
+		Name:          patch.Target.Name,
+		LabelSelector: patch.Target.LabelSelector,
+	}
+	matched, err := resMap.Select(*selector)
+	if err != nil {
+		return 0, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("%v has an invalid target: %v", desc, err),
+		}
+	}
+	if len(matched) == 0 {
+		return 0, nil
+	}
+
+	patchData, err := completePatch(patch.Patch)
+	if err != nil {
+		return 0, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("invalid %v: %v", desc, err),
+		}
+	}
+	c, err := yaml.Marshal(&builtin.PatchTransformerPlugin{Patch: patchData, Target: selector})
+	if err != nil {
+		return 0, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't marshal %v: %v", desc, err),
+		}
+	}
+	rf := resmap.NewFactory(resource.NewFactory(kunstruct.NewKunstructuredFactoryImpl()), transformer.NewFactoryImpl())
+	patcher := &builtin.PatchTransformerPlugin{}
+	if err := patcher.Config(nil, rf, c); err != nil {
+		return 0, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("invalid %v: %v", desc, err),
+		}
+	}
+	if err := patcher.Transform(resMap); err != nil {
+		return 0, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't apply %v: %v", desc, err),
+		}
+	}
+	return len(matched), nil
+}
+
+// completePatch sets the apiVersion, kind and name of a strategic merge patch if it doesn't,
+// since kustomize requires them even though they're replaced by the ones of each resource patched.
+// JSON 6902 patches are returned as is.
+func completePatch(patch string) (string, error) {
+	var obj interface{}
+	if err := yaml.Unmarshal([]byte(patch), &obj); err != nil {
+		return "", err
+	}
+	smp, ok := obj.(map[string]interface{})
+	if !ok {
+		return patch, nil
+	}
+	if _, ok := smp["apiVersion"]; !ok {
+		smp["apiVersion"] = "v1"
+	}
+	if _, ok := smp["kind"]; !ok {
+		smp["kind"] = "Patch"
+	}
+	metadata, ok := smp["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		smp["metadata"] = metadata
+	}
+	if _, ok := metadata["name"]; !ok {
+		metadata["name"] = "patch"
+	}
+	data, err := yaml.Marshal(smp)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// describeTarget returns the fields set in the target of a patch, for messages.
+func describeTarget(target *kfconfig.PatchTarget) string {
+	if target == nil {
+		return "no target"
+	}
+	fields := []string{}
+	for _, f := range []struct{ name, value string }{
+		{"group", target.Group},
+		{"version", target.Version},
+		{"kind", target.Kind},
+		{"name", target.Name},
+		{"namespace", target.Namespace},
+		{"labelSelector", target.LabelSelector},
+	} {
+		if f.value != "" {
+			fields = append(fields, f.name+"="+f.value)
+		}
+	}
+	if len(fields) == 0 {
+		return "any resource"
+	}
+	return strings.Join(fields, ", ")
+}
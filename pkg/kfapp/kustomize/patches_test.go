@@ -0,0 +1,94 @@
+package kustomize
+
+import (
+	"reflect"
+	"testing"
+
+	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTransformPatches(t *testing.T) {
+	resMap := newTestResMap(t, testDeployment)
+	config := &kfconfig.KfConfig{
+		Spec: kfconfig.KfConfigSpec{
+			Patches: []kfconfig.Patch{
+				{
+					Patch:  "spec:\n  replicas: 2\n",
+					Target: &kfconfig.PatchTarget{Kind: "Deployment", LabelSelector: "app=dashboard"},
+				},
+				{
+					Patch:  "spec:\n  replicas: 3\n",
+					Target: &kfconfig.PatchTarget{Kind: "StatefulSet"},
+				},
+			},
+		},
+	}
+	app := kfconfig.Application{
+		Name: "dashboard",
+		Patches: []kfconfig.Patch{
+			{
+				Patch:  `[{"op": "add", "path": "/metadata/annotations", "value": {"example.com/patched": "true"}}]`,
+				Target: &kfconfig.PatchTarget{Group: "apps", Version: "v1", Kind: "Deployment", Name: "dash.*"},
+			},
+			{
+				Patch:  "metadata:\n  labels:\n    patched: \"true\"\n",
+				Target: &kfconfig.PatchTarget{Kind: "Service"},
+			},
+		},
+	}
+	report := &renderReport{}
+	if err := transformPatches(config, app, resMap, report); err != nil {
+		t.Fatalf("Failed to transform patches: %v", err)
+	}
+
+	deployment := resourceMap(t, resMap, "Deployment")
+	if replicas, _, _ := unstructured.NestedInt64(deployment, "spec", "replicas"); replicas != 2 {
+		t.Errorf("Unexpected replicas; got %v", replicas)
+	}
+	if annotation, _, _ := unstructured.NestedString(deployment, "metadata", "annotations", "example.com/patched"); annotation != "true" {
+		t.Errorf("Unexpected annotation; got %v", annotation)
+	}
+	// Patches of the application matching nothing are reported right away, while those of
+	// the KfDef are only reported once all the applications are rendered.
+	expected := []string{"patch 1 of application dashboard (kind=Service) doesn't match any resource"}
+	if !reflect.DeepEqual(report.warnings, expected) {
+		t.Errorf("Unexpected warnings; got %v; want %v", report.warnings, expected)
+	}
+	expected = append(expected, "patch 1 of the KfDef (kind=StatefulSet) doesn't match any resource")
+	if actual := report.finish(config); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected warnings; got %v; want %v", actual, expected)
+	}
+}
+
+func TestTransformPatchesInvalid(t *testing.T) {
+	type testCase struct {
+		name  string
+		patch kfconfig.Patch
+	}
+	testCases := []testCase{
+		{
+			name:  "no-target",
+			patch: kfconfig.Patch{Patch: "spec:\n  replicas: 2\n"},
+		},
+		{
+			name:  "invalid-name",
+			patch: kfconfig.Patch{Patch: "spec:\n  replicas: 2\n", Target: &kfconfig.PatchTarget{Name: "dashboard("}},
+		},
+		{
+			name:  "invalid-patch",
+			patch: kfconfig.Patch{Patch: "- replicas", Target: &kfconfig.PatchTarget{Kind: "Deployment"}},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			app := kfconfig.Application{Name: "dashboard", Patches: []kfconfig.Patch{c.patch}}
+			err := transformPatches(&kfconfig.KfConfig{}, app, newTestResMap(t, testDeployment), &renderReport{})
+			kfErr, ok := err.(*kfapis.KfError)
+			if !ok || kfErr.Code != int(kfapis.INVALID_ARGUMENT) {
+				t.Errorf("Expected an invalid argument error; got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package kustomize
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/kustomize/v3/pkg/resmap"
+	"sigs.k8s.io/kustomize/v3/pkg/resource"
+)
+
+// toUnstructuredValue converts a typed value, e.g. a toleration, into its unstructured form.
+func toUnstructuredValue(obj interface{}) (map[string]interface{}, error) {
+	value, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't convert %T: %v", obj, err),
+		}
+	}
+	return value, nil
+}
+
+// transformPlacement applies the placement of the application to the pod templates of its workloads.
+func transformPlacement(config *kfconfig.KfConfig, app kfconfig.Application, resMap resmap.ResMap, _ *renderReport) error {
+	placement := app.Placement
+	if placement == nil {
+		return nil
+	}
+	tolerations := []interface{}{}
+	for i := range placement.Tolerations {
+		toleration, err := toUnstructuredValue(&placement.Tolerations[i])
+		if err != nil {
+			return err
+		}
+		tolerations = append(tolerations, toleration)
+	}
+	var affinity map[string]interface{}
+	if placement.Affinity != nil {
+		var err error
+		if affinity, err = toUnstructuredValue(placement.Affinity); err != nil {
+			return err
+		}
+	}
+
+	return updatePodSpecs(resMap, func(_ *resource.Resource, podSpec map[string]interface{}) (bool, error) {
+		if len(placement.NodeSelector) > 0 {
+			nodeSelector, _ := podSpec["nodeSelector"].(map[string]interface{})
+			if nodeSelector == nil {
+				nodeSelector = map[string]interface{}{}
+			}
+			for k, v := range placement.NodeSelector {
+				nodeSelector[k] = v
+			}
+			podSpec["nodeSelector"] = nodeSelector
+		}
+		if len(tolerations) > 0 {
+			existing, _ := podSpec["tolerations"].([]interface{})
+			for _, toleration := range tolerations {
+				if !containsValue(existing, toleration) {
+					existing = append(existing, toleration)
+				}
+			}
+			podSpec["tolerations"] = existing
+		}
+		if affinity != nil {
+			podSpec["affinity"] = runtime.DeepCopyJSONValue(affinity)
+		}
+		if placement.PriorityClassName != "" {
+			podSpec["priorityClassName"] = placement.PriorityClassName
+		}
+		return true, nil
+	})
+}
+
+// transformContainerResources overrides the compute resources of the containers of the workloads of the
+// application. The containers set in the application which aren't in any workload are reported.
+func transformContainerResources(config *kfconfig.KfConfig, app kfconfig.Application, resMap resmap.ResMap, report *renderReport) error {
+	if len(app.Resources) == 0 {
+		return nil
+	}
+	overrides := map[string]map[string]interface{}{}
+	for name := range app.Resources {
+		requirements := app.Resources[name]
+		override, err := toUnstructuredValue(&requirements)
+		if err != nil {
+			return err
+		}
+		overrides[name] = override
+	}
+
+	found := map[string]bool{}
+	err := updatePodSpecs(resMap, func(_ *resource.Resource, podSpec map[string]interface{}) (bool, error) {
+		changed := false
+		for _, container := range podContainers(podSpec) {
+			name, _ := container["name"].(string)
+			override, ok := overrides[name]
+			if !ok {
+				continue
+			}
+			found[name] = true
+			resources, _ := container["resources"].(map[string]interface{})
+			if resources == nil {
+				resources = map[string]interface{}{}
+			}
+			for _, field := range []string{"requests", "limits"} {
+				values, ok := override[field].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				merged, _ := resources[field].(map[string]interface{})
+				if merged == nil {
+					merged = map[string]interface{}{}
+				}
+				for k, v := range values {
+					merged[k] = v
+				}
+				resources[field] = merged
+			}
+			container["resources"] = resources
+			changed = true
+		}
+		return changed, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	missing := []string{}
+	for name := range overrides {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		report.warnf("resources of application %v set container %v which isn't in any of its workloads", app.Name, name)
+	}
+	return nil
+}
+
+func containsValue(list []interface{}, value interface{}) bool {
+	for _, v := range list {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
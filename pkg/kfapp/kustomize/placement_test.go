@@ -0,0 +1,85 @@
+package kustomize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTransformPlacement(t *testing.T) {
+	resMap := newTestResMap(t, testDeployment)
+	app := kfconfig.Application{
+		Name: "dashboard",
+		Placement: &kfconfig.Placement{
+			NodeSelector: map[string]string{"node-role.kubernetes.io/infra": ""},
+			Tolerations: []v1.Toleration{
+				{Key: "node-role.kubernetes.io/infra", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule},
+			},
+			PriorityClassName: "odh-critical",
+		},
+	}
+	if err := transformPlacement(&kfconfig.KfConfig{}, app, resMap, &renderReport{}); err != nil {
+		t.Fatalf("Failed to transform placement: %v", err)
+	}
+
+	podSpec, _, _ := unstructured.NestedMap(resourceMap(t, resMap, "Deployment"), "spec", "template", "spec")
+	expected := map[string]interface{}{"node-role.kubernetes.io/infra": ""}
+	if !reflect.DeepEqual(podSpec["nodeSelector"], expected) {
+		t.Errorf("Unexpected node selector; got %v; want %v", podSpec["nodeSelector"], expected)
+	}
+	expectedTolerations := []interface{}{
+		map[string]interface{}{"key": "node-role.kubernetes.io/infra", "operator": "Exists", "effect": "NoSchedule"},
+	}
+	if !reflect.DeepEqual(podSpec["tolerations"], expectedTolerations) {
+		t.Errorf("Unexpected tolerations; got %v; want %v", podSpec["tolerations"], expectedTolerations)
+	}
+	if podSpec["priorityClassName"] != "odh-critical" {
+		t.Errorf("Unexpected priority class; got %v", podSpec["priorityClassName"])
+	}
+
+	// Applying the placement again doesn't duplicate the tolerations.
+	if err := transformPlacement(&kfconfig.KfConfig{}, app, resMap, &renderReport{}); err != nil {
+		t.Fatalf("Failed to transform placement: %v", err)
+	}
+	podSpec, _, _ = unstructured.NestedMap(resourceMap(t, resMap, "Deployment"), "spec", "template", "spec")
+	if !reflect.DeepEqual(podSpec["tolerations"], expectedTolerations) {
+		t.Errorf("Unexpected tolerations; got %v; want %v", podSpec["tolerations"], expectedTolerations)
+	}
+}
+
+func TestTransformContainerResources(t *testing.T) {
+	resMap := newTestResMap(t, testDeployment)
+	app := kfconfig.Application{
+		Name: "dashboard",
+		Resources: map[string]v1.ResourceRequirements{
+			"dashboard": {
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+				Limits:   v1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+			},
+			"oauth-proxy": {
+				Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("64Mi")},
+			},
+		},
+	}
+	report := &renderReport{}
+	if err := transformContainerResources(&kfconfig.KfConfig{}, app, resMap, report); err != nil {
+		t.Fatalf("Failed to transform resources: %v", err)
+	}
+
+	containers, _, _ := unstructured.NestedSlice(resourceMap(t, resMap, "Deployment"), "spec", "template", "spec", "containers")
+	expected := map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "500m"},
+		"limits":   map[string]interface{}{"nvidia.com/gpu": "1"},
+	}
+	if actual := containers[0].(map[string]interface{})["resources"]; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected resources; got %v; want %v", actual, expected)
+	}
+	expectedWarnings := []string{"resources of application dashboard set container oauth-proxy which isn't in any of its workloads"}
+	if !reflect.DeepEqual(report.warnings, expectedWarnings) {
+		t.Errorf("Unexpected warnings; got %v; want %v", report.warnings, expectedWarnings)
+	}
+}
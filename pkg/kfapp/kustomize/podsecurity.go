@@ -0,0 +1,135 @@
+package kustomize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/kustomize/v3/pkg/resmap"
+	"sigs.k8s.io/kustomize/v3/pkg/resource"
+)
+
+// The annotation workloads opt out of the pod security hardening by.
+const skipPodSecurityHardeningAnnotation = "opendatahub.io/skip-pod-security-hardening"
+
+// immutablePodSpecKinds are the workload kinds whose pod spec can't be updated once they're
+// created, so hardening them would fail to apply over the existing ones.
+var immutablePodSpecKinds = map[string]bool{
+	"Pod": true,
+	"Job": true,
+}
+
+// transformPodSecurity makes the workloads of the application meet the restricted Pod Security
+// Standard where their security context is unset, if the application asks for it. The workloads
+// changed are added to the report, and those which can't be hardened are reported as warnings.
+func transformPodSecurity(config *kfconfig.KfConfig, app kfconfig.Application, resMap resmap.ResMap, report *renderReport) error {
+	if !app.HardenPodSecurity {
+		return nil
+	}
+	hardened := []string{}
+	err := updatePodSpecs(resMap, func(r *resource.Resource, podSpec map[string]interface{}) (bool, error) {
+		if r.GetAnnotations()[skipPodSecurityHardeningAnnotation] == "true" {
+			log.Infof("Skipping the pod security hardening of %v %v of application %v", r.GetKind(), r.GetName(), app.Name)
+			return false, nil
+		}
+		if immutablePodSpecKinds[r.GetKind()] {
+			log.Infof("Skipping the pod security hardening of %v %v of application %v, whose pod spec is immutable",
+				r.GetKind(), r.GetName(), app.Name)
+			return false, nil
+		}
+		for _, container := range podContainers(podSpec) {
+			if reason := privilegedContainer(container); reason != "" {
+				report.warnf("%v %v of application %v can't be hardened: container %v %v",
+					r.GetKind(), r.GetName(), app.Name, container["name"], reason)
+				return false, nil
+			}
+		}
+		changed := hardenPodSecurityContext(podSpec)
+		for _, container := range podContainers(podSpec) {
+			if hardenContainerSecurityContext(container) {
+				changed = true
+			}
+		}
+		if changed {
+			hardened = append(hardened, fmt.Sprintf("%v %v", r.GetKind(), r.GetName()))
+		}
+		return changed, nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(hardened) > 0 {
+		msg := fmt.Sprintf("application %v: %v", app.Name, strings.Join(hardened, ", "))
+		log.Infof("Hardened the pod security of the workloads of %v", msg)
+		report.hardened = append(report.hardened, msg)
+	}
+	return nil
+}
+
+// securityContext returns the security context of a pod spec or container, adding it if it's unset.
+func securityContext(obj map[string]interface{}) map[string]interface{} {
+	sc, ok := obj["securityContext"].(map[string]interface{})
+	if !ok {
+		sc = map[string]interface{}{}
+		obj["securityContext"] = sc
+	}
+	return sc
+}
+
+// setDefault sets the field of obj to value if it's unset, and returns true if it did.
+func setDefault(obj map[string]interface{}, field string, value interface{}) bool {
+	if _, ok := obj[field]; ok {
+		return false
+	}
+	obj[field] = value
+	return true
+}
+
+// hardenPodSecurityContext makes the pods run as non root with the RuntimeDefault seccomp profile,
+// unless their security context says otherwise.
+func hardenPodSecurityContext(podSpec map[string]interface{}) bool {
+	sc := securityContext(podSpec)
+	changed := setDefault(sc, "runAsNonRoot", true)
+	if setDefault(sc, "seccompProfile", map[string]interface{}{"type": "RuntimeDefault"}) {
+		changed = true
+	}
+	return changed
+}
+
+// privilegedContainer returns why the container requires privilege escalation, or an empty string
+// if it doesn't: privileged containers and containers adding CAP_SYS_ADMIN can't disallow it.
+func privilegedContainer(container map[string]interface{}) string {
+	sc, _ := container["securityContext"].(map[string]interface{})
+	if privileged, _ := sc["privileged"].(bool); privileged {
+		return "is privileged"
+	}
+	capabilities, _ := sc["capabilities"].(map[string]interface{})
+	add, _ := capabilities["add"].([]interface{})
+	for _, c := range add {
+		if c == "SYS_ADMIN" || c == "CAP_SYS_ADMIN" {
+			return "adds CAP_SYS_ADMIN"
+		}
+	}
+	return ""
+}
+
+// hardenContainerSecurityContext makes the container disallow privilege escalation unless its
+// security context allows it, and drop all capabilities, keeping the ones it adds.
+func hardenContainerSecurityContext(container map[string]interface{}) bool {
+	sc := securityContext(container)
+	changed := setDefault(sc, "allowPrivilegeEscalation", false)
+	capabilities, ok := sc["capabilities"].(map[string]interface{})
+	if !ok {
+		capabilities = map[string]interface{}{}
+		sc["capabilities"] = capabilities
+	}
+	drop, _ := capabilities["drop"].([]interface{})
+	for _, c := range drop {
+		if c == "ALL" {
+			return changed
+		}
+	}
+	capabilities["drop"] = append(drop, "ALL")
+	return true
+}
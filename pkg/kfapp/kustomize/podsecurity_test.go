@@ -0,0 +1,164 @@
+package kustomize
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const testCustomizedStatefulSet = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: migrate
+spec:
+  template:
+    spec:
+      securityContext:
+        runAsNonRoot: false
+      containers:
+      - name: migrate
+        image: quay.io/opendatahub/migrate:v1.0
+        securityContext:
+          capabilities:
+            add:
+            - NET_ADMIN
+`
+
+const testOptedOutJob = `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: setup
+  annotations:
+    opendatahub.io/skip-pod-security-hardening: "true"
+spec:
+  template:
+    spec:
+      containers:
+      - name: setup
+        image: quay.io/opendatahub/setup:v1.0
+`
+
+const testJob = `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: cleanup
+spec:
+  template:
+    spec:
+      containers:
+      - name: cleanup
+        image: quay.io/opendatahub/cleanup:v1.0
+`
+
+const testPrivilegedDaemonSet = `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: node-agent
+spec:
+  template:
+    spec:
+      containers:
+      - name: agent
+        image: quay.io/opendatahub/agent:v1.0
+        securityContext:
+          privileged: true
+`
+
+const testSysAdminDaemonSet = `apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: fuse-mounter
+spec:
+  template:
+    spec:
+      containers:
+      - name: mounter
+        image: quay.io/opendatahub/mounter:v1.0
+        securityContext:
+          capabilities:
+            add:
+            - SYS_ADMIN
+`
+
+func TestTransformPodSecurity(t *testing.T) {
+	resMap := newTestResMap(t, strings.Join([]string{testDeployment, testCustomizedStatefulSet, testOptedOutJob,
+		testJob, testPrivilegedDaemonSet, testSysAdminDaemonSet}, "---\n"))
+	app := kfconfig.Application{Name: "dashboard", HardenPodSecurity: true}
+	report := &renderReport{}
+	if err := transformPodSecurity(&kfconfig.KfConfig{}, app, resMap, report); err != nil {
+		t.Fatalf("Failed to transform pod security: %v", err)
+	}
+
+	podSpec, _, _ := unstructured.NestedMap(resourceMap(t, resMap, "Deployment"), "spec", "template", "spec")
+	expectedPod := map[string]interface{}{
+		"runAsNonRoot":   true,
+		"seccompProfile": map[string]interface{}{"type": "RuntimeDefault"},
+	}
+	if !reflect.DeepEqual(podSpec["securityContext"], expectedPod) {
+		t.Errorf("Unexpected pod security context; got %v; want %v", podSpec["securityContext"], expectedPod)
+	}
+	expectedContainer := map[string]interface{}{
+		"allowPrivilegeEscalation": false,
+		"capabilities":             map[string]interface{}{"drop": []interface{}{"ALL"}},
+	}
+	for _, container := range podContainers(podSpec) {
+		if !reflect.DeepEqual(container["securityContext"], expectedContainer) {
+			t.Errorf("Unexpected security context of container %v; got %v; want %v", container["name"], container["securityContext"], expectedContainer)
+		}
+	}
+
+	// What the workloads set explicitly is kept.
+	for _, r := range resMap.Resources() {
+		if r.GetName() != "migrate" {
+			continue
+		}
+		podSpec, _, _ := unstructured.NestedMap(r.Map(), "spec", "template", "spec")
+		if runAsNonRoot, _, _ := unstructured.NestedBool(podSpec, "securityContext", "runAsNonRoot"); runAsNonRoot {
+			t.Errorf("Expected runAsNonRoot to be kept false")
+		}
+		capabilities, _, _ := unstructured.NestedMap(podContainers(podSpec)[0], "securityContext", "capabilities")
+		expected := map[string]interface{}{"add": []interface{}{"NET_ADMIN"}, "drop": []interface{}{"ALL"}}
+		if !reflect.DeepEqual(capabilities, expected) {
+			t.Errorf("Unexpected capabilities; got %v; want %v", capabilities, expected)
+		}
+	}
+
+	expectedReport := []string{"application dashboard: Deployment dashboard, StatefulSet migrate"}
+	if !reflect.DeepEqual(report.hardened, expectedReport) {
+		t.Errorf("Unexpected hardened workloads; got %v; want %v", report.hardened, expectedReport)
+	}
+	expectedWarnings := []string{
+		"DaemonSet node-agent of application dashboard can't be hardened: container agent is privileged",
+		"DaemonSet fuse-mounter of application dashboard can't be hardened: container mounter adds CAP_SYS_ADMIN",
+	}
+	if !reflect.DeepEqual(report.warnings, expectedWarnings) {
+		t.Errorf("Unexpected warnings; got %v; want %v", report.warnings, expectedWarnings)
+	}
+
+	// The workloads which aren't hardened are left as they are.
+	for _, r := range resMap.Resources() {
+		if r.GetKind() != "Job" && r.GetKind() != "DaemonSet" {
+			continue
+		}
+		fields := podSpecFields[r.GetKind()]
+		if _, found, _ := unstructured.NestedMap(r.Map(), append(fields, "securityContext")...); found {
+			t.Errorf("Expected the pod security context of %v %v to be left unset", r.GetKind(), r.GetName())
+		}
+		podSpec, _, _ := unstructured.NestedMap(r.Map(), fields...)
+		if _, found, _ := unstructured.NestedFieldNoCopy(podContainers(podSpec)[0], "securityContext", "allowPrivilegeEscalation"); found {
+			t.Errorf("Expected allowPrivilegeEscalation of %v %v to be left unset", r.GetKind(), r.GetName())
+		}
+	}
+
+	// The workloads are left alone once they're hardened.
+	report = &renderReport{}
+	if err := transformPodSecurity(&kfconfig.KfConfig{}, app, resMap, report); err != nil {
+		t.Fatalf("Failed to transform pod security: %v", err)
+	}
+	if len(report.hardened) > 0 {
+		t.Errorf("Expected no hardened workloads; got %v", report.hardened)
+	}
+}
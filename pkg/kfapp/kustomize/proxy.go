@@ -0,0 +1,124 @@
+package kustomize
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig/openshiftplugin"
+	"sigs.k8s.io/kustomize/v3/pkg/resmap"
+	"sigs.k8s.io/kustomize/v3/pkg/resource"
+)
+
+const (
+	// The volume the trusted CA bundle is mounted from.
+	trustedCABundleVolume = "trusted-ca-bundle"
+	// The directory of the CA bundles of RHEL based images, the trusted CA bundle is mounted into.
+	trustedCABundleDir = "/etc/pki/ca-trust/extracted/pem"
+	// The key of the trusted CA bundle in its ConfigMap, as injected by the cluster network operator.
+	trustedCABundleKey = "ca-bundle.crt"
+	// The file the trusted CA bundle is mounted as.
+	trustedCABundleFile = "tls-ca-bundle.pem"
+)
+
+// proxyEnv returns the environment variables of the proxy settings, in upper and lower case
+// since tools disagree on which one they read.
+func proxyEnv(proxy *kfconfig.Proxy) [][2]string {
+	env := [][2]string{}
+	for _, v := range [][2]string{{"HTTP_PROXY", proxy.HTTPProxy}, {"HTTPS_PROXY", proxy.HTTPSProxy}, {"NO_PROXY", proxy.NoProxy}} {
+		if v[1] == "" {
+			continue
+		}
+		env = append(env, v, [2]string{strings.ToLower(v[0]), v[1]})
+	}
+	return env
+}
+
+// transformProxy injects the proxy settings and the trusted CA bundle of the config into the
+// containers of the workloads. With FromCluster, the settings of the cluster wide proxy are loaded
+// from the ConfigMaps of the OpenShift plugin, which are created once the applications are applied,
+// so the containers don't require them to exist. The trusted CA bundle is required though: it's
+// mounted over the bundle of the system CAs only, which a missing ConfigMap would leave empty.
+func transformProxy(config *kfconfig.KfConfig, app kfconfig.Application, resMap resmap.ResMap, _ *renderReport) error {
+	proxy := config.Spec.Proxy
+	if proxy == nil {
+		return nil
+	}
+	env := proxyEnv(proxy)
+	proxyConfigMap, caBundle := "", proxy.TrustedCABundle
+	if proxy.FromCluster {
+		spec := &openshiftplugin.OpenShiftPluginSpec{}
+		if err := config.GetPluginSpec(kfconfig.OPENSHIFT_PLUGIN_KIND, spec); err != nil || spec.ClusterProxy == nil {
+			return &kfapisv3.KfError{
+				Code:    int(kfapisv3.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("the proxy loads the cluster proxy settings but the %v doesn't set a clusterProxy", kfconfig.OPENSHIFT_PLUGIN_KIND),
+			}
+		}
+		proxyConfigMap = spec.ClusterProxy.GetConfigMapName()
+		if caBundle == "" {
+			caBundle = spec.ClusterProxy.GetTrustedCABundleConfigMapName()
+		}
+	}
+
+	return updatePodSpecs(resMap, func(_ *resource.Resource, podSpec map[string]interface{}) (bool, error) {
+		if caBundle != "" {
+			podSpec["volumes"] = appendNamed(podSpec["volumes"], map[string]interface{}{
+				"name": trustedCABundleVolume,
+				"configMap": map[string]interface{}{
+					"name": caBundle,
+					"items": []interface{}{
+						map[string]interface{}{"key": trustedCABundleKey, "path": trustedCABundleFile},
+					},
+				},
+			})
+		}
+		for _, container := range podContainers(podSpec) {
+			if proxyConfigMap != "" {
+				envFrom := map[string]interface{}{
+					"configMapRef": map[string]interface{}{"name": proxyConfigMap, "optional": true},
+				}
+				list, _ := container["envFrom"].([]interface{})
+				if !containsValue(list, envFrom) {
+					container["envFrom"] = append(list, envFrom)
+				}
+			}
+			for _, v := range env {
+				container["env"] = setNamed(container["env"], map[string]interface{}{"name": v[0], "value": v[1]})
+			}
+			if caBundle != "" {
+				container["volumeMounts"] = appendNamed(container["volumeMounts"], map[string]interface{}{
+					"name":      trustedCABundleVolume,
+					"mountPath": path.Join(trustedCABundleDir, trustedCABundleFile),
+					"subPath":   trustedCABundleFile,
+					"readOnly":  true,
+				})
+			}
+		}
+		return true, nil
+	})
+}
+
+// appendNamed appends the item to the list unless the list has an item with the same name.
+func appendNamed(list interface{}, item map[string]interface{}) []interface{} {
+	items, _ := list.([]interface{})
+	for _, i := range items {
+		if m, ok := i.(map[string]interface{}); ok && m["name"] == item["name"] {
+			return items
+		}
+	}
+	return append(items, item)
+}
+
+// setNamed replaces the item of the list with the same name, or appends it.
+func setNamed(list interface{}, item map[string]interface{}) []interface{} {
+	items, _ := list.([]interface{})
+	for j, i := range items {
+		if m, ok := i.(map[string]interface{}); ok && m["name"] == item["name"] {
+			items[j] = item
+			return items
+		}
+	}
+	return append(items, item)
+}
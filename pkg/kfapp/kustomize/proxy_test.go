@@ -0,0 +1,84 @@
+package kustomize
+
+import (
+	"reflect"
+	"testing"
+
+	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig/openshiftplugin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTransformProxy(t *testing.T) {
+	resMap := newTestResMap(t, testDeployment)
+	config := &kfconfig.KfConfig{
+		Spec: kfconfig.KfConfigSpec{
+			Proxy: &kfconfig.Proxy{
+				HTTPSProxy:  "http://proxy.example.com:3128",
+				FromCluster: true,
+			},
+		},
+	}
+	config.SetPluginSpec(kfconfig.OPENSHIFT_PLUGIN_KIND, &openshiftplugin.OpenShiftPluginSpec{
+		ClusterProxy: &openshiftplugin.ClusterProxy{},
+	})
+	// Transforming the resources twice doesn't duplicate what's injected.
+	for i := 0; i < 2; i++ {
+		if err := transformProxy(config, kfconfig.Application{Name: "dashboard"}, resMap, &renderReport{}); err != nil {
+			t.Fatalf("Failed to transform proxy: %v", err)
+		}
+	}
+
+	podSpec, _, _ := unstructured.NestedMap(resourceMap(t, resMap, "Deployment"), "spec", "template", "spec")
+	expectedVolumes := []interface{}{
+		map[string]interface{}{
+			"name": "trusted-ca-bundle",
+			"configMap": map[string]interface{}{
+				"name": "trusted-cabundle",
+				"items": []interface{}{
+					map[string]interface{}{"key": "ca-bundle.crt", "path": "tls-ca-bundle.pem"},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(podSpec["volumes"], expectedVolumes) {
+		t.Errorf("Unexpected volumes; got %v; want %v", podSpec["volumes"], expectedVolumes)
+	}
+	expectedContainer := map[string]interface{}{
+		"envFrom": []interface{}{
+			map[string]interface{}{"configMapRef": map[string]interface{}{"name": "cluster-proxy", "optional": true}},
+		},
+		"env": []interface{}{
+			map[string]interface{}{"name": "HTTPS_PROXY", "value": "http://proxy.example.com:3128"},
+			map[string]interface{}{"name": "https_proxy", "value": "http://proxy.example.com:3128"},
+		},
+		"volumeMounts": []interface{}{
+			map[string]interface{}{
+				"name":      "trusted-ca-bundle",
+				"mountPath": "/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem",
+				"subPath":   "tls-ca-bundle.pem",
+				"readOnly":  true,
+			},
+		},
+	}
+	for _, container := range podContainers(podSpec) {
+		for field, expected := range expectedContainer {
+			if !reflect.DeepEqual(container[field], expected) {
+				t.Errorf("Unexpected %v of container %v; got %v; want %v", field, container["name"], container[field], expected)
+			}
+		}
+	}
+}
+
+func TestTransformProxyNoClusterProxy(t *testing.T) {
+	config := &kfconfig.KfConfig{
+		Spec: kfconfig.KfConfigSpec{
+			Proxy: &kfconfig.Proxy{FromCluster: true},
+		},
+	}
+	err := transformProxy(config, kfconfig.Application{Name: "dashboard"}, newTestResMap(t, testDeployment), &renderReport{})
+	if kfErr, ok := err.(*kfapis.KfError); !ok || kfErr.Code != int(kfapis.INVALID_ARGUMENT) {
+		t.Errorf("Expected an invalid argument error; got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package kustomize
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/utils"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	errutil "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// neverDeletedKinds are the kinds of resources which are not deleted by the ownership labels,
+// since deleting them deletes everything they hold. They are only deleted as rendered.
+var neverDeletedKinds = map[string]bool{
+	"Namespace":                true,
+	"CustomResourceDefinition": true,
+}
+
+// renderedObjects returns the kinds and the references of the resources of the rendered yaml,
+// except the kinds never deleted by the ownership labels. Resources without a namespace are
+// applied in the namespace of the KfDef if they are namespaced, so they are referenced in both.
+func renderedObjects(data []byte, namespace string) ([]schema.GroupVersionKind, map[utils.ObjectKey]bool, error) {
+	resources, err := utils.SplitYAML(data)
+	if err != nil {
+		return nil, nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("error splitting yaml: %v", err),
+		}
+	}
+	kinds := []schema.GroupVersionKind{}
+	refs := map[utils.ObjectKey]bool{}
+	for _, r := range resources {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(r, obj); err != nil {
+			return nil, nil, &kfapisv3.KfError{
+				Code:    int(kfapisv3.INTERNAL_ERROR),
+				Message: fmt.Sprintf("error decoding rendered resource: %v", err),
+			}
+		}
+		if obj.GetKind() == "" || neverDeletedKinds[obj.GetKind()] {
+			continue
+		}
+		kinds = appendKinds(kinds, []schema.GroupVersionKind{obj.GroupVersionKind()})
+		key := utils.NewObjectRef(obj).Key()
+		refs[key] = true
+		if key.Namespace == "" {
+			key.Namespace = namespace
+			refs[key] = true
+		}
+	}
+	return kinds, refs, nil
+}
+
+// appendKinds appends the kinds which aren't in kinds yet.
+func appendKinds(kinds []schema.GroupVersionKind, more []schema.GroupVersionKind) []schema.GroupVersionKind {
+	for _, gvk := range more {
+		found := false
+		for _, k := range kinds {
+			if k == gvk {
+				found = true
+				break
+			}
+		}
+		if !found {
+			kinds = append(kinds, gvk)
+		}
+	}
+	return kinds
+}
+
+// previousKinds returns the kinds of the resources rendered at the last apply, except the kinds
+// never deleted by the ownership labels, so the resources of kinds no longer rendered are found.
+func (kustomize *kustomize) previousKinds() []schema.GroupVersionKind {
+	kinds := []schema.GroupVersionKind{}
+	for _, kind := range kustomize.kfDef.Status.RenderedKinds {
+		if neverDeletedKinds[kind.Kind] {
+			continue
+		}
+		kinds = appendKinds(kinds, []schema.GroupVersionKind{{Group: kind.Group, Version: kind.Version, Kind: kind.Kind}})
+	}
+	return kinds
+}
+
+// prune deletes the resources labeled as managed for the application which it no longer renders.
+// The kinds of the resources it renders and of those rendered at the last apply are looked up.
+func (kustomize *kustomize) prune(app string, data []byte) error {
+	labels := utils.OwnershipLabels(kustomize.kfDef.Name, kustomize.kfDef.Namespace, app)
+	kinds, keep, err := renderedObjects(data, kustomize.kfDef.Namespace)
+	if err != nil {
+		return err
+	}
+	kinds = appendKinds(kinds, kustomize.previousKinds())
+	if err := kustomize.initK8sClients(); err != nil {
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("kustomize plugin couldn't initialize a K8s client: %v", err),
+		}
+	}
+	kubeclient, err := client.New(kustomize.restConfig, client.Options{})
+	if err != nil {
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("error initializing k8s client: %v", err),
+		}
+	}
+	return pruneResources(kubeclient, app, kinds, labels, keep)
+}
+
+// pruneResources deletes the resources of the kinds matching the ownership labels of the
+// application which aren't kept.
+func pruneResources(kubeclient client.Client, app string, kinds []schema.GroupVersionKind,
+	labels map[string]string, keep map[utils.ObjectKey]bool) error {
+	pruned, err := utils.DeleteOwnedResources(context.TODO(), kubeclient, kinds, labels, keep)
+	if err != nil {
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("error pruning application %v: %v", app, err),
+		}
+	}
+	if len(pruned) > 0 {
+		log.Infof("Pruned %v resources of application %v", len(pruned), app)
+	}
+	return nil
+}
+
+// deleteApplication deletes the resources labeled as managed for the application, of the kinds it
+// renders and of those rendered at the last apply, in uninstall order, and waits until they are
+// gone. The namespaces and CRDs it renders are then deleted as rendered.
+func deleteApplication(kubeclient client.Client, app string, data []byte, previousKinds []schema.GroupVersionKind,
+	labels map[string]string, namespace string, timeout time.Duration) error {
+	kinds, _, err := renderedObjects(data, namespace)
+	if err != nil {
+		return err
+	}
+	kinds = appendKinds(kinds, previousKinds)
+	utils.SortKinds(kinds, utils.UninstallOrder)
+	deleted, err := utils.DeleteOwnedResources(context.TODO(), kubeclient, kinds, labels, nil)
+	if err != nil {
+		return fmt.Errorf("error deleting application %v: %v", app, err)
+	}
+	if err := utils.WaitForDeleted(context.TODO(), kubeclient, deleted, timeout); err != nil {
+		return fmt.Errorf("error deleting application %v: %v", app, err)
+	}
+
+	resources, err := utils.SplitYAML(data)
+	if err != nil {
+		return fmt.Errorf("error splitting yaml: %v", err)
+	}
+	errs := []error{}
+	for _, r := range resources {
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(r, obj); err != nil {
+			return fmt.Errorf("error decoding rendered resource: %v", err)
+		}
+		if !neverDeletedKinds[obj.GetKind()] {
+			continue
+		}
+		if err := utils.DeleteResource(r, kubeclient, timeout, true); err != nil {
+			errs = append(errs, fmt.Errorf("error deleting application %v: %v", app, err))
+		}
+	}
+	return errutil.NewAggregate(errs)
+}
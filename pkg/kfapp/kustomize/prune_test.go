@@ -0,0 +1,150 @@
+package kustomize
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/utils"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRenderedObjects(t *testing.T) {
+	data := []byte(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: odh
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: dashboard-config
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: dashboard-config
+  namespace: other
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: dashboard
+`)
+	kinds, refs, err := renderedObjects(data, "odh")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	clusterRole := schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}
+	if len(kinds) != 2 || kinds[0] != configMap || kinds[1] != clusterRole {
+		t.Errorf("unexpected kinds %v", kinds)
+	}
+	expected := []utils.ObjectKey{
+		{GroupKind: configMap.GroupKind(), Name: "dashboard-config"},
+		{GroupKind: configMap.GroupKind(), Namespace: "odh", Name: "dashboard-config"},
+		{GroupKind: configMap.GroupKind(), Namespace: "other", Name: "dashboard-config"},
+		{GroupKind: clusterRole.GroupKind(), Name: "dashboard"},
+		{GroupKind: clusterRole.GroupKind(), Namespace: "odh", Name: "dashboard"},
+	}
+	for _, ref := range expected {
+		if !refs[ref] {
+			t.Errorf("expected %v to be rendered", ref)
+		}
+	}
+	if len(refs) != len(expected) {
+		t.Errorf("unexpected rendered objects %v", refs)
+	}
+}
+
+func TestPreviousKinds(t *testing.T) {
+	config := &kfconfig.KfConfig{}
+	config.Status.RenderedKinds = []metav1.GroupVersionKind{
+		{Version: "v1", Kind: "Namespace"},
+		{Version: "v1", Kind: "ConfigMap"},
+		{Group: "route.openshift.io", Version: "v1", Kind: "Route"},
+		{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+	}
+	kinds := (&kustomize{kfDef: config}).previousKinds()
+	expected := []schema.GroupVersionKind{
+		{Version: "v1", Kind: "ConfigMap"},
+		{Group: "route.openshift.io", Version: "v1", Kind: "Route"},
+	}
+	if len(kinds) != len(expected) || kinds[0] != expected[0] || kinds[1] != expected[1] {
+		t.Errorf("unexpected kinds %v", kinds)
+	}
+}
+
+func TestDeleteApplication(t *testing.T) {
+	labels := utils.OwnershipLabels("opendatahub", "odh", "dashboard")
+	annotations := map[string]string{utils.KfDefAnnotation + "/" + utils.KfDefInstance: "opendatahub.odh"}
+	kubeclient := fake.NewClientBuilder().WithObjects(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dashboard", Labels: labels, Annotations: annotations}},
+		&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "odh", Name: "rendered", Labels: labels}},
+		// of a kind only rendered at the last apply
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "odh", Name: "removed", Labels: labels}},
+		&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "odh", Name: "unmanaged"}},
+	).Build()
+
+	data := []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: rendered
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: dashboard
+`)
+	previous := []schema.GroupVersionKind{{Version: "v1", Kind: "Service"}}
+	if err := deleteApplication(kubeclient, "dashboard", data, previous, labels, "odh", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	configMaps := &v1.ConfigMapList{}
+	services := &v1.ServiceList{}
+	namespaces := &v1.NamespaceList{}
+	for _, list := range []client.ObjectList{configMaps, services, namespaces} {
+		if err := kubeclient.List(context.TODO(), list); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	names := []string{}
+	for _, cm := range configMaps.Items {
+		names = append(names, cm.Namespace+"/"+cm.Name)
+	}
+	if strings.Join(names, ",") != "odh/unmanaged" {
+		t.Errorf("unexpected remaining config maps %v", names)
+	}
+	if len(services.Items) != 0 || len(namespaces.Items) != 0 {
+		t.Errorf("unexpected remaining resources %v, %v", services.Items, namespaces.Items)
+	}
+}
+
+func TestDeleteApplication_NotRendered(t *testing.T) {
+	labels := utils.OwnershipLabels("opendatahub", "odh", "dashboard")
+	kubeclient := fake.NewClientBuilder().WithObjects(
+		&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "odh", Name: "dashboard-config", Labels: labels}},
+		&v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "odh", Name: "unmanaged"}},
+	).Build()
+
+	// the package of the application isn't on disk, so only the kinds of the last apply are known
+	previous := []schema.GroupVersionKind{{Version: "v1", Kind: "ConfigMap"}}
+	if err := deleteApplication(kubeclient, "dashboard", nil, previous, labels, "odh", time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	configMaps := &v1.ConfigMapList{}
+	if err := kubeclient.List(context.TODO(), configMaps); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(configMaps.Items) != 1 || configMaps.Items[0].Name != "unmanaged" {
+		t.Errorf("unexpected remaining config maps %v", configMaps.Items)
+	}
+}
@@ -0,0 +1,156 @@
+package kustomize
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/kustomize/v3/pkg/resmap"
+	"sigs.k8s.io/kustomize/v3/pkg/resource"
+)
+
+// secretCopy is a Secret to copy into another namespace.
+type secretCopy struct {
+	name string
+	from string
+	to   string
+}
+
+// applicationPullSecrets returns the image pull secrets of the application: the ones of the
+// KfDef, replaced by the ones of the application with the same name.
+func applicationPullSecrets(config *kfconfig.KfConfig, app kfconfig.Application) []kfconfig.ImagePullSecret {
+	secrets := []kfconfig.ImagePullSecret{}
+	overridden := map[string]bool{}
+	for _, secret := range app.ImagePullSecrets {
+		overridden[secret.Name] = true
+	}
+	for _, secret := range config.Spec.ImagePullSecrets {
+		if !overridden[secret.Name] {
+			secrets = append(secrets, secret)
+		}
+	}
+	return append(secrets, app.ImagePullSecrets...)
+}
+
+// transformPullSecrets adds the image pull secrets of the application to its workloads and
+// ServiceAccounts, and sets the image pull policy of the containers of its workloads.
+// The Secrets to copy into the namespaces of these resources are added to the report.
+func transformPullSecrets(config *kfconfig.KfConfig, app kfconfig.Application, resMap resmap.ResMap, report *renderReport) error {
+	secrets := applicationPullSecrets(config, app)
+	policy := app.ImagePullPolicy
+	if policy == "" {
+		policy = config.Spec.ImagePullPolicy
+	}
+	switch policy {
+	case "", v1.PullAlways, v1.PullIfNotPresent, v1.PullNever:
+	default:
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("invalid image pull policy %v of application %v; expected %v, %v or %v", policy, app.Name, v1.PullAlways, v1.PullIfNotPresent, v1.PullNever),
+		}
+	}
+	if len(secrets) == 0 && policy == "" {
+		return nil
+	}
+	for _, secret := range secrets {
+		// The operator can read the Secrets of any namespace, while the users creating a KfDef may not.
+		if secret.CopyFromNamespace != "" && secret.CopyFromNamespace != config.Namespace {
+			return &kfapisv3.KfError{
+				Code: int(kfapisv3.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("image pull secret %v of application %v can only be copied from namespace %v of the KfDef, not %v",
+					secret.Name, app.Name, config.Namespace, secret.CopyFromNamespace),
+			}
+		}
+	}
+
+	addSecrets := func(r *resource.Resource, obj map[string]interface{}) {
+		for _, secret := range secrets {
+			obj["imagePullSecrets"] = appendNamed(obj["imagePullSecrets"], map[string]interface{}{"name": secret.Name})
+			namespace := r.GetNamespace()
+			if namespace == "" {
+				namespace = config.Namespace
+			}
+			if secret.CopyFromNamespace != "" && secret.CopyFromNamespace != namespace {
+				report.addSecretCopy(secretCopy{name: secret.Name, from: secret.CopyFromNamespace, to: namespace})
+			}
+		}
+	}
+	for _, r := range resMap.Resources() {
+		if r.GetKind() == "ServiceAccount" && len(secrets) > 0 {
+			obj := r.Map()
+			addSecrets(r, obj)
+			r.SetMap(obj)
+		}
+	}
+	return updatePodSpecs(resMap, func(r *resource.Resource, podSpec map[string]interface{}) (bool, error) {
+		addSecrets(r, podSpec)
+		if policy != "" {
+			for _, container := range podContainers(podSpec) {
+				container["imagePullPolicy"] = string(policy)
+			}
+		}
+		return true, nil
+	})
+}
+
+// addSecretCopy adds a Secret to copy to the report, unless it's already there.
+func (r *renderReport) addSecretCopy(c secretCopy) {
+	for _, existing := range r.secretCopies {
+		if existing == c {
+			return
+		}
+	}
+	r.secretCopies = append(r.secretCopies, c)
+}
+
+// copySecrets copies the Secrets into their target namespaces, replacing the data of the copies
+// which already exist. The copies are labeled so they are deleted along with the KfDef.
+func copySecrets(kubeClient kubernetes.Interface, copies []secretCopy, labels map[string]string) error {
+	sort.Slice(copies, func(i, j int) bool {
+		return copies[i].to+"/"+copies[i].name < copies[j].to+"/"+copies[j].name
+	})
+	ctx := context.TODO()
+	for _, c := range copies {
+		source, err := kubeClient.CoreV1().Secrets(c.from).Get(ctx, c.name, metav1.GetOptions{})
+		if err != nil {
+			return &kfapisv3.KfError{
+				Code:    int(kfapisv3.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("couldn't read image pull secret %v/%v: %v", c.from, c.name, err),
+			}
+		}
+		log.Infof("Copying image pull secret %v from namespace %v to %v", c.name, c.from, c.to)
+		secrets := kubeClient.CoreV1().Secrets(c.to)
+		existing, err := secrets.Get(ctx, c.name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			_, err = secrets.Create(ctx, &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: c.name, Namespace: c.to, Labels: labels},
+				Type:       source.Type,
+				Data:       source.Data,
+			}, metav1.CreateOptions{})
+		case err == nil:
+			existing.Data = source.Data
+			if len(labels) > 0 && existing.Labels == nil {
+				existing.Labels = map[string]string{}
+			}
+			for k, v := range labels {
+				existing.Labels[k] = v
+			}
+			_, err = secrets.Update(ctx, existing, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return &kfapisv3.KfError{
+				Code:    int(kfapisv3.INTERNAL_ERROR),
+				Message: fmt.Sprintf("couldn't copy image pull secret %v to namespace %v: %v", c.name, c.to, err),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package kustomize
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testServiceAccount = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: dashboard
+  namespace: odh-dashboard
+`
+
+func TestTransformPullSecrets(t *testing.T) {
+	resMap := newTestResMap(t, testDeployment+"---\n"+testServiceAccount)
+	config := &kfconfig.KfConfig{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "opendatahub"},
+		Spec: kfconfig.KfConfigSpec{
+			ImagePullSecrets: []kfconfig.ImagePullSecret{
+				{Name: "registry-pull-secret", CopyFromNamespace: "opendatahub"},
+				{Name: "mirror-pull-secret"},
+			},
+			ImagePullPolicy: v1.PullAlways,
+		},
+	}
+	app := kfconfig.Application{
+		Name:            "dashboard",
+		ImagePullPolicy: v1.PullIfNotPresent,
+	}
+	report := &renderReport{}
+	if err := transformPullSecrets(config, app, resMap, report); err != nil {
+		t.Fatalf("Failed to transform pull secrets: %v", err)
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{"name": "registry-pull-secret"},
+		map[string]interface{}{"name": "mirror-pull-secret"},
+	}
+	podSpec, _, _ := unstructured.NestedMap(resourceMap(t, resMap, "Deployment"), "spec", "template", "spec")
+	if !reflect.DeepEqual(podSpec["imagePullSecrets"], expected) {
+		t.Errorf("Unexpected pod image pull secrets; got %v; want %v", podSpec["imagePullSecrets"], expected)
+	}
+	if actual := resourceMap(t, resMap, "ServiceAccount")["imagePullSecrets"]; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Unexpected service account image pull secrets; got %v; want %v", actual, expected)
+	}
+	for _, container := range podContainers(podSpec) {
+		if container["imagePullPolicy"] != "IfNotPresent" {
+			t.Errorf("Unexpected image pull policy of container %v; got %v", container["name"], container["imagePullPolicy"])
+		}
+	}
+	// The Deployment is in the namespace of the KfDef, so the Secret is only copied for the ServiceAccount.
+	expectedCopies := []secretCopy{{name: "registry-pull-secret", from: "opendatahub", to: "odh-dashboard"}}
+	if !reflect.DeepEqual(report.secretCopies, expectedCopies) {
+		t.Errorf("Unexpected secret copies; got %v; want %v", report.secretCopies, expectedCopies)
+	}
+
+	app.ImagePullSecrets = []kfconfig.ImagePullSecret{{Name: "registry-pull-secret", CopyFromNamespace: "openshift-config"}}
+	err := transformPullSecrets(config, app, newTestResMap(t, testDeployment), &renderReport{})
+	if kfErr, ok := err.(*kfapis.KfError); !ok || kfErr.Code != int(kfapis.INVALID_ARGUMENT) {
+		t.Errorf("Expected an invalid argument error copying a secret from another namespace; got %v", err)
+	}
+}
+
+func TestCopySecrets(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry-pull-secret", Namespace: "opendatahub"},
+			Type:       v1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{v1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry-pull-secret", Namespace: "odh-notebooks"},
+			Type:       v1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{v1.DockerConfigJsonKey: []byte(`{}`)},
+		},
+	)
+	copies := []secretCopy{
+		{name: "registry-pull-secret", from: "opendatahub", to: "odh-dashboard"},
+		{name: "registry-pull-secret", from: "opendatahub", to: "odh-notebooks"},
+	}
+	labels := map[string]string{"kfctl.kubeflow.io/kfdef-name": "opendatahub"}
+	if err := copySecrets(kubeClient, copies, labels); err != nil {
+		t.Fatalf("Failed to copy secrets: %v", err)
+	}
+	for _, namespace := range []string{"odh-dashboard", "odh-notebooks"} {
+		secret, err := kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), "registry-pull-secret", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get the secret in %v: %v", namespace, err)
+		}
+		if secret.Type != v1.SecretTypeDockerConfigJson || string(secret.Data[v1.DockerConfigJsonKey]) != `{"auths":{}}` {
+			t.Errorf("Unexpected secret in %v; got %v", namespace, secret)
+		}
+		if !reflect.DeepEqual(secret.Labels, labels) {
+			t.Errorf("Unexpected labels of the secret in %v; got %v; want %v", namespace, secret.Labels, labels)
+		}
+	}
+
+	if err := copySecrets(kubeClient, []secretCopy{{name: "missing", from: "opendatahub", to: "odh-dashboard"}}, labels); err == nil {
+		t.Errorf("Expected an error copying a missing secret")
+	}
+}
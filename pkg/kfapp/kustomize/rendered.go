@@ -0,0 +1,60 @@
+package kustomize
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/ghodss/yaml"
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/v3/pkg/types"
+)
+
+// generatedResourcesFile is the file holding the rendered resources of an application in the
+// kustomize package generated for it.
+const generatedResourcesFile = "resources.yaml"
+
+// writeResourcesPackage writes the resources rendered for an application to appDir along with
+// a kustomization listing them, so they are evaluated like any other kustomize package when
+// the application is applied or deleted.
+func writeResourcesPackage(appDir string, appName string, objects []*unstructured.Unstructured) error {
+	resources, err := renderers.EncodeObjects(objects)
+	if err != nil {
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("can not encode component %v as yaml: %v", appName, err),
+		}
+	}
+	if err := os.MkdirAll(appDir, os.ModePerm); err != nil {
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't create directory %v: %v", appDir, err),
+		}
+	}
+	if err := ioutil.WriteFile(path.Join(appDir, generatedResourcesFile), resources, 0644); err != nil {
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't write resources for application %v: %v", appName, err),
+		}
+	}
+	kustomization, err := yaml.Marshal(&types.Kustomization{
+		Resources: []string{generatedResourcesFile},
+	})
+	if err != nil {
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't marshal kustomization for application %v: %v", appName, err),
+		}
+	}
+	if err := ioutil.WriteFile(path.Join(appDir, kftypesv3.KustomizationFile), kustomization, 0644); err != nil {
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't write kustomization for application %v: %v", appName, err),
+		}
+	}
+	return nil
+}
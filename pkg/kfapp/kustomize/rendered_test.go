@@ -0,0 +1,35 @@
+package kustomize
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers"
+)
+
+func TestWriteResourcesPackage(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	appDir := path.Join(testDir, "kustomize", "component")
+	resources := "apiVersion: v1\nkind: Service\nmetadata:\n  name: component\n"
+	objects, err := renderers.DecodeObjects([]byte(resources))
+	if err != nil {
+		t.Fatalf("could not decode resources; %v", err)
+	}
+	if err := writeResourcesPackage(appDir, "component", objects); err != nil {
+		t.Fatalf("could not write package; %v", err)
+	}
+
+	kustomization := GetKustomization(appDir)
+	if kustomization == nil || !reflect.DeepEqual(kustomization.Resources, []string{generatedResourcesFile}) {
+		t.Fatalf("kustomization; got %+v; want resources %v", kustomization, generatedResourcesFile)
+	}
+	actual, err := ioutil.ReadFile(path.Join(appDir, generatedResourcesFile))
+	if err != nil || string(actual) != resources {
+		t.Fatalf("resources; got %v, %v; want %v", string(actual), err, resources)
+	}
+}
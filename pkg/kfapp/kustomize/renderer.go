@@ -0,0 +1,145 @@
+package kustomize
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"github.com/otiai10/copy"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// packagesDir is the directory of the app dir the kustomize packages of the applications are
+// staged in before they are evaluated.
+const packagesDir = ".packages"
+
+func init() {
+	renderers.Register("kustomize", kustomizeRenderer{})
+}
+
+// kustomizeRenderer renders applications setting a KustomizeConfig from their kustomize package,
+// with the overlays and parameters of the KustomizeConfig.
+type kustomizeRenderer struct{}
+
+func (kustomizeRenderer) Handles(app kfconfig.Application) bool {
+	return app.KustomizeConfig != nil
+}
+
+func (kustomizeRenderer) Render(config *kfconfig.KfConfig, app kfconfig.Application) ([]*unstructured.Unstructured, error) {
+	appPath, err := kustomizeSourceDir(config, app)
+	if err != nil {
+		return nil, err
+	}
+	stagingDir := path.Join(config.Spec.AppDir, packagesDir)
+	appDir := path.Join(stagingDir, app.Name)
+
+	if config.UsingStacks() {
+		if filepath.IsAbs(appPath) {
+			// The appPath needs to be a relative path because we use it as a resource location in the kustomize
+			// file
+			absAppDir, err := filepath.Abs(config.Spec.AppDir)
+			if err != nil {
+				return nil, errors.WithStack(fmt.Errorf("There was a problem computing absolute path of %v; error; %v ", config.Spec.AppDir, err))
+			}
+			relPath, err := filepath.Rel(absAppDir, appPath)
+			if err != nil {
+				return nil, errors.WithStack(fmt.Errorf("There was a problem computing filePath.Rel(%v, %v); error; %v ", absAppDir, appPath, err))
+			}
+
+			appPath = relPath
+		}
+		// Path to the stack inside the cache.
+		stacksCacheDir := filepath.Join("../..", appPath)
+		if _, err := createStackAppKustomization(appDir, stacksCacheDir); err != nil {
+			return nil, errors.WithStack(fmt.Errorf("There was a problem building the kustomize app for the Kubeflow application stack; %v ", err))
+		}
+	} else {
+		// TODO(jlewi): This code path should eventually go away once we are fully migrated to the use
+		// of stacks.
+		// Copy the component to the staging dir
+		_ = os.RemoveAll(appDir)
+		if err := copy.Copy(appPath, appDir); err != nil {
+			return nil, &kfapisv3.KfError{
+				Code:    int(kfapisv3.INTERNAL_ERROR),
+				Message: fmt.Sprintf("couldn't copy application %s: %v", app.Name, err),
+			}
+		}
+		params, err := config.ResolveParameters(app.KustomizeConfig.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		if err := ValidateOverlays(app.Name, appDir, app.KustomizeConfig.Overlays); err != nil {
+			return nil, err
+		}
+		if err := GenerateKustomizationFile(config, stagingDir, app.Name,
+			app.KustomizeConfig.Overlays, params); err != nil {
+			// Invalid configs, e.g. missing overlays, are returned as is.
+			if kfErr, ok := err.(*kfapisv3.KfError); ok && kfErr.Code == int(kfapisv3.INVALID_ARGUMENT) {
+				return nil, err
+			}
+			return nil, &kfapisv3.KfError{
+				Code:    int(kfapisv3.INTERNAL_ERROR),
+				Message: fmt.Sprintf("couldn't generate kustomization file for component %s: %v", app.Name, err),
+			}
+		}
+		if err := overrideParamImages(appDir, app.KustomizeConfig.Overlays, applicationImages(config, app)); err != nil {
+			return nil, err
+		}
+	}
+
+	// The resources are updated from the ones in the cluster when the rendered package is evaluated.
+	resMap, err := buildKustomizeManifest(appDir)
+	if err != nil {
+		log.Errorf("Error evaluating kustomization manifest for %v: %v", app.Name, err)
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("error evaluating kustomization manifest for %v: %v", app.Name, err),
+		}
+	}
+	var objects []*unstructured.Unstructured
+	for _, r := range resMap.Resources() {
+		objects = append(objects, &unstructured.Unstructured{Object: r.Map()})
+	}
+	return objects, nil
+}
+
+// kustomizeSourceDir returns the directory of the kustomize package of the application in the repos cache.
+func kustomizeSourceDir(config *kfconfig.KfConfig, app kfconfig.Application) (string, error) {
+	repoName := app.KustomizeConfig.RepoRef.Name
+	repoCache, ok := config.GetRepoCache(repoName)
+	if !ok {
+		err := fmt.Errorf("application %v refers to repo %v which wasn't found in KfDef.Status.ReposCache", app.Name, repoName)
+		log.Errorf("%v", err)
+		return "", &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: err.Error(),
+		}
+	}
+	return path.Join(repoCache.LocalPath, app.KustomizeConfig.RepoRef.Path), nil
+}
+
+// unknownParametersMessage returns a message listing the parameters the application sets which
+// aren't declared in the params.env of its kustomize package, or an empty string if there are none.
+func unknownParametersMessage(config *kfconfig.KfConfig, app kfconfig.Application) (string, error) {
+	appPath, err := kustomizeSourceDir(config, app)
+	if err != nil {
+		return "", err
+	}
+	params, err := config.ResolveParameters(app.KustomizeConfig.Parameters)
+	if err != nil {
+		return "", err
+	}
+	unknown, err := UnknownParameters(appPath, app.KustomizeConfig.Overlays, params)
+	if err != nil || len(unknown) == 0 {
+		return "", err
+	}
+	return fmt.Sprintf("application %v sets parameters %v which aren't declared in its params.env",
+		app.Name, strings.Join(unknown, ", ")), nil
+}
@@ -0,0 +1,103 @@
+package kustomize
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+)
+
+func TestKustomizeRenderer(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	repoDir := path.Join(testDir, "manifests")
+	files := map[string]string{
+		"dashboard/base/kustomization.yaml": "resources:\n- service.yaml\n",
+		"dashboard/base/service.yaml":       "apiVersion: v1\nkind: Service\nmetadata:\n  name: dashboard\n",
+	}
+	for name, content := range files {
+		os.MkdirAll(path.Dir(path.Join(repoDir, name)), os.ModePerm)
+		ioutil.WriteFile(path.Join(repoDir, name), []byte(content), 0644)
+	}
+
+	config := &kfconfig.KfConfig{
+		Spec: kfconfig.KfConfigSpec{AppDir: path.Join(testDir, "app")},
+		Status: kfconfig.Status{
+			Caches: []kfconfig.Cache{{Name: "manifests", LocalPath: repoDir}},
+		},
+	}
+	app := kfconfig.Application{
+		Name: "dashboard",
+		KustomizeConfig: &kfconfig.KustomizeConfig{
+			RepoRef: &kfconfig.RepoRef{Name: "manifests", Path: "dashboard"},
+		},
+	}
+
+	name, renderer, ok := renderers.ForApplication(app)
+	if !ok || name != "kustomize" {
+		t.Fatalf("expected the kustomize renderer to handle the application; got %v, %v", name, ok)
+	}
+	objects, err := renderer.Render(config, app)
+	if err != nil {
+		t.Fatalf("could not render application; %v", err)
+	}
+	if len(objects) != 1 || objects[0].GetKind() != "Service" || objects[0].GetName() != "dashboard" {
+		t.Fatalf("objects; got %v", objects)
+	}
+
+	app.KustomizeConfig.RepoRef.Name = "other"
+	if _, err := renderer.Render(config, app); err == nil {
+		t.Fatalf("expected an error rendering an application of a missing repo")
+	}
+}
+
+func TestGenerate_SelectedApplications(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	repoDir := path.Join(testDir, "manifests")
+	files := map[string]string{
+		"dashboard/base/kustomization.yaml": "resources:\n- service.yaml\n",
+		"dashboard/base/service.yaml":       "apiVersion: v1\nkind: Service\nmetadata:\n  name: dashboard\n",
+		"notebooks/base/kustomization.yaml": "resources:\n- service.yaml\n",
+		"notebooks/base/service.yaml":       "apiVersion: v1\nkind: Service\nmetadata:\n  name: notebooks\n",
+	}
+	for name, content := range files {
+		os.MkdirAll(path.Dir(path.Join(repoDir, name)), os.ModePerm)
+		ioutil.WriteFile(path.Join(repoDir, name), []byte(content), 0644)
+	}
+
+	newApp := func(name string) kfconfig.Application {
+		return kfconfig.Application{
+			Name: name,
+			KustomizeConfig: &kfconfig.KustomizeConfig{
+				RepoRef: &kfconfig.RepoRef{Name: "manifests", Path: name},
+			},
+		}
+	}
+	config := &kfconfig.KfConfig{
+		Spec: kfconfig.KfConfigSpec{
+			AppDir:       path.Join(testDir, "app"),
+			Applications: []kfconfig.Application{newApp("dashboard"), newApp("notebooks")},
+		},
+		Status: kfconfig.Status{
+			Caches: []kfconfig.Cache{{Name: "manifests", LocalPath: repoDir}},
+		},
+	}
+	config.SetAnnotations(map[string]string{"kfctl.kubeflow.io/applications": "notebooks"})
+
+	if err := GetKfApp(config).Generate(kftypesv3.K8S); err != nil {
+		t.Fatalf("could not generate applications; %v", err)
+	}
+	if _, err := os.Stat(path.Join(testDir, "app", outputDir, "notebooks", generatedResourcesFile)); err != nil {
+		t.Errorf("expected the selected application to be generated; %v", err)
+	}
+	if _, err := os.Stat(path.Join(testDir, "app", outputDir, "dashboard")); !os.IsNotExist(err) {
+		t.Errorf("expected the other application not to be generated; got %v", err)
+	}
+}
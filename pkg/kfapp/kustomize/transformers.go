@@ -0,0 +1,88 @@
+package kustomize
+
+import (
+	"fmt"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kustomize/v3/pkg/resmap"
+)
+
+// renderTransformer modifies the resources of an application once they are rendered,
+// e.g. to apply the overrides set in the KfDef without forking the manifests.
+// Issues which shouldn't fail the render are added to the report.
+type renderTransformer func(config *kfconfig.KfConfig, app kfconfig.Application, resMap resmap.ResMap, report *renderReport) error
+
+// renderTransformers are applied in order to the resources of every application when it's rendered.
+// The patches are applied last, so they can override what the other transformers set.
+var renderTransformers = []renderTransformer{
+	transformImages,
+	transformPlacement,
+	transformContainerResources,
+	transformProxy,
+	transformPullSecrets,
+	transformPodSecurity,
+	transformPatches,
+}
+
+// renderReport collects what the render transformers report while the applications are rendered.
+type renderReport struct {
+	warnings []string
+	// kfDefPatchMatches counts the resources matched by each of the patches of the KfDef,
+	// by index, over all the applications.
+	kfDefPatchMatches map[int]int
+	// secretCopies are the Secrets to copy into the namespaces of the resources rendered,
+	// once they are applied.
+	secretCopies []secretCopy
+	// hardened lists the workloads whose pod security was hardened, by application.
+	hardened []string
+	// renderedKinds are the kinds of the resources rendered, in the order they were first rendered.
+	renderedKinds []metav1.GroupVersionKind
+}
+
+// warnf logs a warning and adds it to the report.
+func (r *renderReport) warnf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Warnf(msg)
+	r.warnings = append(r.warnings, msg)
+}
+
+// addRenderedKinds adds the kinds of the resources which aren't in the report yet.
+func (r *renderReport) addRenderedKinds(resMap resmap.ResMap) {
+	for _, res := range resMap.Resources() {
+		gvk := res.GetGvk()
+		kind := metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind}
+		found := false
+		for _, k := range r.renderedKinds {
+			if k == kind {
+				found = true
+				break
+			}
+		}
+		if !found {
+			r.renderedKinds = append(r.renderedKinds, kind)
+		}
+	}
+}
+
+// finish returns the warnings of the report, once all the applications of the config are rendered.
+// The patches of the KfDef are only reported if they didn't match any resource of any application.
+func (r *renderReport) finish(config *kfconfig.KfConfig) []string {
+	for i := range config.Spec.Patches {
+		if r.kfDefPatchMatches[i] == 0 {
+			r.warnf("patch %v of the KfDef (%v) doesn't match any resource", i, describeTarget(config.Spec.Patches[i].Target))
+		}
+	}
+	return r.warnings
+}
+
+// transformResources applies the render transformers to the resources of the application.
+func transformResources(config *kfconfig.KfConfig, app kfconfig.Application, resMap resmap.ResMap, report *renderReport) error {
+	for _, transform := range renderTransformers {
+		if err := transform(config, app, resMap, report); err != nil {
+			return err
+		}
+	}
+	return nil
+}
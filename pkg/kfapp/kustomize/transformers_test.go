@@ -0,0 +1,87 @@
+package kustomize
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/v3/k8sdeps/kunstruct"
+	"sigs.k8s.io/kustomize/v3/k8sdeps/transformer"
+	"sigs.k8s.io/kustomize/v3/pkg/resmap"
+	"sigs.k8s.io/kustomize/v3/pkg/resource"
+)
+
+const testDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: dashboard
+  labels:
+    app: dashboard
+spec:
+  selector:
+    matchLabels:
+      app: dashboard
+  template:
+    metadata:
+      labels:
+        app: dashboard
+    spec:
+      initContainers:
+      - name: init
+        image: registry.access.redhat.com/ubi8/ubi-minimal:latest
+      containers:
+      - name: dashboard
+        image: quay.io/opendatahub/odh-dashboard:v1.0
+`
+
+// newTestResMap returns the resources as a ResMap, as they would be rendered for an application.
+func newTestResMap(t *testing.T, resources string) resmap.ResMap {
+	rf := resmap.NewFactory(resource.NewFactory(kunstruct.NewKunstructuredFactoryImpl()), transformer.NewFactoryImpl())
+	resMap, err := rf.NewResMapFromBytes([]byte(resources))
+	if err != nil {
+		t.Fatalf("Failed to read resources: %v", err)
+	}
+	return resMap
+}
+
+// resourceMap returns the resource of the given kind as a map.
+func resourceMap(t *testing.T, resMap resmap.ResMap, kind string) map[string]interface{} {
+	for _, r := range resMap.Resources() {
+		if r.GetKind() == kind {
+			return r.Map()
+		}
+	}
+	t.Fatalf("No %v in resources", kind)
+	return nil
+}
+
+func TestTransformResources(t *testing.T) {
+	resMap := newTestResMap(t, testDeployment)
+	config := &kfconfig.KfConfig{
+		Spec: kfconfig.KfConfigSpec{
+			Images: []kfconfig.Image{{Name: "quay.io/opendatahub/odh-dashboard", NewName: "mirror.example.com/odh-dashboard"}},
+		},
+	}
+	if err := transformResources(config, kfconfig.Application{Name: "dashboard"}, resMap, &renderReport{}); err != nil {
+		t.Fatalf("Failed to transform resources: %v", err)
+	}
+	containers, _, _ := unstructured.NestedSlice(resourceMap(t, resMap, "Deployment"), "spec", "template", "spec", "containers")
+	if image := containers[0].(map[string]interface{})["image"]; image != "mirror.example.com/odh-dashboard:v1.0" {
+		t.Errorf("Unexpected image; got %v", image)
+	}
+}
+
+func TestAddRenderedKinds(t *testing.T) {
+	report := &renderReport{}
+	report.addRenderedKinds(newTestResMap(t, testDeployment+"---\n"+testServiceAccount))
+	report.addRenderedKinds(newTestResMap(t, testDeployment))
+	expected := []metav1.GroupVersionKind{
+		{Group: "apps", Version: "v1", Kind: "Deployment"},
+		{Version: "v1", Kind: "ServiceAccount"},
+	}
+	if !reflect.DeepEqual(report.renderedKinds, expected) {
+		t.Errorf("Unexpected rendered kinds; got %v", report.renderedKinds)
+	}
+}
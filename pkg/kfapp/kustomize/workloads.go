@@ -0,0 +1,72 @@
+package kustomize
+
+import (
+	"fmt"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/v3/pkg/resmap"
+	"sigs.k8s.io/kustomize/v3/pkg/resource"
+)
+
+// podSpecFields are the fields holding the pod spec of the workload kinds.
+var podSpecFields = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"DeploymentConfig":      {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// updatePodSpecs calls update with the pod spec of each workload of the resources, and writes
+// it back into the workload. update returns false if it didn't change the pod spec.
+func updatePodSpecs(resMap resmap.ResMap, update func(r *resource.Resource, podSpec map[string]interface{}) (bool, error)) error {
+	for _, r := range resMap.Resources() {
+		fields, ok := podSpecFields[r.GetKind()]
+		if !ok {
+			continue
+		}
+		obj := r.Map()
+		podSpec, found, err := unstructured.NestedMap(obj, fields...)
+		if err != nil || !found {
+			continue
+		}
+		changed, err := update(r, podSpec)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			continue
+		}
+		if err := unstructured.SetNestedMap(obj, podSpec, fields...); err != nil {
+			return &kfapisv3.KfError{
+				Code:    int(kfapisv3.INTERNAL_ERROR),
+				Message: fmt.Sprintf("couldn't update the pod spec of %v %v: %v", r.GetKind(), r.GetName(), err),
+			}
+		}
+		r.SetMap(obj)
+	}
+	return nil
+}
+
+// podContainers returns the containers and init containers of a pod spec. Changes to them are
+// made in the pod spec.
+func podContainers(podSpec map[string]interface{}) []map[string]interface{} {
+	containers := []map[string]interface{}{}
+	for _, field := range []string{"initContainers", "containers"} {
+		list, ok := podSpec[field].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, c := range list {
+			if container, ok := c.(map[string]interface{}); ok {
+				containers = append(containers, container)
+			}
+		}
+	}
+	return containers
+}
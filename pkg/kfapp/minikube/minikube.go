@@ -24,6 +24,7 @@ import (
 	//"github.com/kubeflow/kfctl/v3/config"
 	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
 	kftypes "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/platforms"
 	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
 
 	//kfdefs "github.com/kubeflow/kfctl/v3/pkg/apis/apps/kfdef/v1alpha1"
@@ -43,6 +44,12 @@ type Minikube struct {
 	kfconfig.KfConfig
 }
 
+func init() {
+	platforms.Register(kfconfig.MINIKUBE_PLUGIN_KIND, kftypes.MINIKUBE, func(kfdef *kfconfig.KfConfig) (kftypes.Platform, error) {
+		return Getplatform(kfdef), nil
+	})
+}
+
 func Getplatform(kfdef *kfconfig.KfConfig) kftypes.Platform {
 	_minikube := &Minikube{
 		KfConfig: *kfdef,
@@ -0,0 +1,458 @@
+package openshift
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/platforms"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig/openshiftplugin"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/utils"
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// The label the cluster network operator injects the trusted CA bundle into ConfigMaps by.
+	injectTrustedCABundleLabel = "config.openshift.io/inject-trusted-cabundle"
+	// The annotation the service CA operator creates a serving certificate for a service by.
+	servingCertSecretAnnotation = "service.beta.openshift.io/serving-cert-secret-name"
+	// The annotation prefix making a service account an OAuth client redirecting to a route.
+	oauthRedirectReferenceAnnotation = "serviceaccounts.openshift.io/oauth-redirectreference."
+	// The name of the cluster wide proxy config.
+	clusterProxyName = "cluster"
+)
+
+var proxyGVK = schema.GroupVersionKind{Group: "config.openshift.io", Version: "v1", Kind: "Proxy"}
+
+// ownedKinds are the kinds of the namespaced resources the platform creates for the KfDef. They
+// are labeled with the ownership labels of the KfDef and deleted with it.
+var ownedKinds = []schema.GroupVersionKind{
+	rbacv1.SchemeGroupVersion.WithKind("RoleBinding"),
+	routev1.SchemeGroupVersion.WithKind("Route"),
+	corev1.SchemeGroupVersion.WithKind("ServiceAccount"),
+	corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+	corev1.SchemeGroupVersion.WithKind("Secret"),
+}
+
+// OpenShift sets up the KfDef namespace for OpenShift: SCC bindings, routes, cluster proxy and
+// trusted CA propagation, and OAuth proxy wiring.
+type OpenShift struct {
+	*kfconfig.KfConfig
+	client client.Client
+}
+
+func init() {
+	platforms.Register(kfconfig.OPENSHIFT_PLUGIN_KIND, kftypesv3.OPENSHIFT, GetPlatform)
+}
+
+// GetPlatform returns the openshift kfapp. It's registered as the openshift platform in the platforms package.
+func GetPlatform(kfdef *kfconfig.KfConfig) (kftypesv3.Platform, error) {
+	return &OpenShift{KfConfig: kfdef}, nil
+}
+
+// GetPluginSpec gets the plugin spec.
+func (openshift *OpenShift) GetPluginSpec() (*openshiftplugin.OpenShiftPluginSpec, error) {
+	spec := &openshiftplugin.OpenShiftPluginSpec{}
+	err := openshift.KfConfig.GetPluginSpec(kfconfig.OPENSHIFT_PLUGIN_KIND, spec)
+	return spec, err
+}
+
+func (openshift *OpenShift) Init(resources kftypesv3.ResourceEnum) error {
+	return nil
+}
+
+func (openshift *OpenShift) Generate(resources kftypesv3.ResourceEnum) error {
+	return nil
+}
+
+func (openshift *OpenShift) Dump(resources kftypesv3.ResourceEnum) error {
+	return nil
+}
+
+// Apply is a no-op: there is nothing to set up for the cluster itself. The KfDef namespace is set
+// up by ApplyK8S.
+func (openshift *OpenShift) Apply(resources kftypesv3.ResourceEnum) error {
+	return nil
+}
+
+// ApplyK8S implements platforms.K8SPlatform: it sets up the KfDef namespace once the applications
+// are applied, since the setup refers to their resources, e.g. the services routes point to.
+func (openshift *OpenShift) ApplyK8S() error {
+	spec, err := openshift.GetPluginSpec()
+	if err != nil {
+		return err
+	}
+	if isValid, msg := spec.IsValid(); !isValid {
+		return &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("invalid %v: %v", kfconfig.OPENSHIFT_PLUGIN_KIND, msg),
+		}
+	}
+
+	kubeclient, err := openshift.getClient()
+	if err != nil {
+		return internalError(errors.WithStack(err))
+	}
+	ctx := context.TODO()
+
+	for _, scc := range spec.SecurityContextConstraints {
+		if err := openshift.applySCCBinding(ctx, kubeclient, scc); err != nil {
+			return internalError(errors.WithStack(err))
+		}
+	}
+	for _, r := range spec.Routes {
+		if err := openshift.applyRoute(ctx, kubeclient, r); err != nil {
+			return internalError(errors.WithStack(err))
+		}
+	}
+	if spec.ClusterProxy != nil {
+		if err := openshift.applyClusterProxy(ctx, kubeclient, spec.ClusterProxy); err != nil {
+			return internalError(errors.WithStack(err))
+		}
+	}
+	for _, p := range spec.OAuthProxies {
+		if err := openshift.applyOAuthProxy(ctx, kubeclient, p); err != nil {
+			return internalError(errors.WithStack(err))
+		}
+	}
+	return nil
+}
+
+// Delete is a no-op, like Apply.
+func (openshift *OpenShift) Delete(resources kftypesv3.ResourceEnum) error {
+	return nil
+}
+
+// DeleteK8S implements platforms.K8SPlatform: it deletes the resources set up for the KfDef: the
+// resources of the ownedKinds labeled as owned by it, and the ones its spec names in case they
+// couldn't be labeled. The SCC cluster roles are shared by the KfDefs using the same SCC, so they
+// are only deleted once no role binding refers to them anymore.
+func (openshift *OpenShift) DeleteK8S() error {
+	spec, err := openshift.GetPluginSpec()
+	if err != nil {
+		return err
+	}
+	kubeclient, err := openshift.getClient()
+	if err != nil {
+		return internalError(errors.WithStack(err))
+	}
+	ctx := context.TODO()
+
+	if _, err := utils.DeleteOwnedResources(ctx, kubeclient, ownedKinds, openshift.ownershipLabels(), nil); err != nil {
+		return internalError(errors.WithStack(err))
+	}
+
+	var objs []client.Object
+	for _, scc := range spec.SecurityContextConstraints {
+		objs = append(objs, &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: sccClusterRoleName(scc.Name), Namespace: openshift.Namespace}})
+	}
+	for _, r := range spec.Routes {
+		objs = append(objs, &routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: r.Name, Namespace: openshift.Namespace}})
+	}
+	if p := spec.ClusterProxy; p != nil {
+		objs = append(objs,
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: p.GetConfigMapName(), Namespace: openshift.Namespace}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: p.GetTrustedCABundleConfigMapName(), Namespace: openshift.Namespace}})
+	}
+	for _, p := range spec.OAuthProxies {
+		objs = append(objs, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: oauthConfigSecretName(p), Namespace: openshift.Namespace}})
+	}
+	for _, obj := range objs {
+		if err := kubeclient.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return internalError(errors.WithStack(err))
+		}
+	}
+
+	for _, scc := range spec.SecurityContextConstraints {
+		if err := deleteSCCClusterRole(ctx, kubeclient, sccClusterRoleName(scc.Name)); err != nil {
+			return internalError(errors.WithStack(err))
+		}
+	}
+	return nil
+}
+
+// deleteSCCClusterRole deletes the SCC cluster role unless a role binding still refers to it.
+func deleteSCCClusterRole(ctx context.Context, kubeclient client.Client, name string) error {
+	bindings := &rbacv1.RoleBindingList{}
+	if err := kubeclient.List(ctx, bindings); err != nil {
+		return err
+	}
+	for _, b := range bindings.Items {
+		if b.RoleRef.Kind == "ClusterRole" && b.RoleRef.Name == name && b.DeletionTimestamp.IsZero() {
+			log.Infof("Keeping cluster role %v which is still bound in namespace %v", name, b.Namespace)
+			return nil
+		}
+	}
+	log.Infof("Deleting cluster role %v", name)
+	err := kubeclient.Delete(ctx, &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// ownershipLabels returns the ownership labels of the resources the platform creates for the KfDef.
+func (openshift *OpenShift) ownershipLabels() map[string]string {
+	return utils.OwnershipLabels(openshift.Name, openshift.Namespace, kftypesv3.OPENSHIFT)
+}
+
+// setOwnershipLabels labels the object as owned by the KfDef, so it's deleted with it.
+func (openshift *OpenShift) setOwnershipLabels(obj metav1.Object) {
+	labels := openshift.ownershipLabels()
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = map[string]string{}
+	}
+	for k, v := range labels {
+		objLabels[k] = v
+	}
+	obj.SetLabels(objLabels)
+}
+
+// ownsObject returns true if the object is labeled as owned by the platform for the KfDef.
+func (openshift *OpenShift) ownsObject(obj metav1.Object) bool {
+	for k, v := range openshift.ownershipLabels() {
+		if obj.GetLabels()[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (openshift *OpenShift) getClient() (client.Client, error) {
+	if openshift.client != nil {
+		return openshift.client, nil
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := routev1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	kubeclient, err := client.New(kftypesv3.GetConfig(), client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+	openshift.client = kubeclient
+	return kubeclient, nil
+}
+
+// sccClusterRoleName returns the name of the cluster role allowed to use the SCC.
+func sccClusterRoleName(scc string) string {
+	return "opendatahub-scc-" + scc
+}
+
+// applySCCBinding binds the service accounts to a cluster role allowed to use the SCC, like
+// `oc adm policy add-scc-to-user` does. Only the openshiftplugin.AllowedSCCs can be used.
+func (openshift *OpenShift) applySCCBinding(ctx context.Context, kubeclient client.Client, scc openshiftplugin.SCCBinding) error {
+	log.Infof("Allowing service accounts %v to use SCC %v", scc.ServiceAccounts, scc.Name)
+	role := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: sccClusterRoleName(scc.Name)}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, kubeclient, role, func() error {
+		role.Rules = []rbacv1.PolicyRule{{
+			APIGroups:     []string{"security.openshift.io"},
+			Resources:     []string{"securitycontextconstraints"},
+			ResourceNames: []string{scc.Name},
+			Verbs:         []string{"use"},
+		}}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	binding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: sccClusterRoleName(scc.Name), Namespace: openshift.Namespace}}
+	_, err := controllerutil.CreateOrUpdate(ctx, kubeclient, binding, func() error {
+		openshift.setOwnershipLabels(binding)
+		binding.RoleRef = rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: role.Name}
+		binding.Subjects = nil
+		for _, sa := range scc.ServiceAccounts {
+			binding.Subjects = append(binding.Subjects, rbacv1.Subject{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      sa,
+				Namespace: openshift.Namespace,
+			})
+		}
+		return nil
+	})
+	return err
+}
+
+func (openshift *OpenShift) applyRoute(ctx context.Context, kubeclient client.Client, r openshiftplugin.Route) error {
+	log.Infof("Creating route %v", r.Name)
+	route := &routev1.Route{ObjectMeta: metav1.ObjectMeta{Name: r.Name, Namespace: openshift.Namespace}}
+	_, err := controllerutil.CreateOrUpdate(ctx, kubeclient, route, func() error {
+		openshift.setOwnershipLabels(route)
+		service := r.Service
+		if service == "" {
+			service = r.Name
+		}
+		route.Spec.To = routev1.RouteTargetReference{Kind: "Service", Name: service}
+		route.Spec.Port = nil
+		if r.TargetPort != "" {
+			route.Spec.Port = &routev1.RoutePort{TargetPort: intstr.Parse(r.TargetPort)}
+		}
+		// The host is generated by the router if it isn't set, so it's only overwritten if it is.
+		if r.Host != "" {
+			route.Spec.Host = r.Host
+		}
+		route.Spec.TLS = nil
+		if r.TLSTermination != "" {
+			route.Spec.TLS = &routev1.TLSConfig{
+				Termination:                   routev1.TLSTerminationType(r.TLSTermination),
+				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyRedirect,
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// applyClusterProxy writes the settings of the cluster wide proxy to a ConfigMap workloads can
+// load their environment from, and creates the ConfigMap the trusted CA bundle is injected into.
+func (openshift *OpenShift) applyClusterProxy(ctx context.Context, kubeclient client.Client, p *openshiftplugin.ClusterProxy) error {
+	env, err := getClusterProxyEnv(ctx, kubeclient)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Writing the cluster proxy settings to ConfigMap %v", p.GetConfigMapName())
+	proxyConfigMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: p.GetConfigMapName(), Namespace: openshift.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, kubeclient, proxyConfigMap, func() error {
+		openshift.setOwnershipLabels(proxyConfigMap)
+		proxyConfigMap.Data = env
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// The data is managed by the cluster network operator, so only the label is set.
+	log.Infof("Injecting the trusted CA bundle into ConfigMap %v", p.GetTrustedCABundleConfigMapName())
+	caConfigMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: p.GetTrustedCABundleConfigMapName(), Namespace: openshift.Namespace}}
+	_, err = controllerutil.CreateOrUpdate(ctx, kubeclient, caConfigMap, func() error {
+		openshift.setOwnershipLabels(caConfigMap)
+		if caConfigMap.Labels == nil {
+			caConfigMap.Labels = map[string]string{}
+		}
+		caConfigMap.Labels[injectTrustedCABundleLabel] = "true"
+		return nil
+	})
+	return err
+}
+
+// getClusterProxyEnv returns the proxy environment variables set by the cluster wide proxy.
+// It returns no variables if the cluster has no proxy config.
+func getClusterProxyEnv(ctx context.Context, kubeclient client.Client) (map[string]string, error) {
+	proxy := &unstructured.Unstructured{}
+	proxy.SetGroupVersionKind(proxyGVK)
+	err := kubeclient.Get(ctx, types.NamespacedName{Name: clusterProxyName}, proxy)
+	if meta.IsNoMatchError(err) || apierrors.IsNotFound(err) {
+		log.Infof("The cluster has no proxy config")
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	env := map[string]string{}
+	for field, name := range map[string]string{"httpProxy": "HTTP_PROXY", "httpsProxy": "HTTPS_PROXY", "noProxy": "NO_PROXY"} {
+		if v, _, _ := unstructured.NestedString(proxy.Object, "status", field); v != "" {
+			env[name] = v
+		}
+	}
+	return env, nil
+}
+
+// applyOAuthProxy makes the service account an OAuth client redirecting to the route, gives the
+// service a serving certificate and creates the cookie secret of the OAuth proxy.
+func (openshift *OpenShift) applyOAuthProxy(ctx context.Context, kubeclient client.Client, p openshiftplugin.OAuthProxy) error {
+	log.Infof("Wiring the OAuth proxy of service %v", p.Service)
+	reference, err := json.Marshal(map[string]interface{}{
+		"kind":       "OAuthRedirectReference",
+		"apiVersion": "v1",
+		"reference":  map[string]string{"kind": "Route", "name": p.Route},
+	})
+	if err != nil {
+		return err
+	}
+	// The service account is only owned by the KfDef if the platform creates it, not if an
+	// application does, so it isn't deleted from under the application.
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: p.ServiceAccount, Namespace: openshift.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, kubeclient, sa, func() error {
+		if sa.ResourceVersion == "" || openshift.ownsObject(sa) {
+			openshift.setOwnershipLabels(sa)
+		}
+		if sa.Annotations == nil {
+			sa.Annotations = map[string]string{}
+		}
+		sa.Annotations[oauthRedirectReferenceAnnotation+p.Route] = string(reference)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// The service is created by an application, so it's only annotated once it exists.
+	service := &corev1.Service{}
+	err = kubeclient.Get(ctx, types.NamespacedName{Name: p.Service, Namespace: openshift.Namespace}, service)
+	if apierrors.IsNotFound(err) {
+		log.Warnf("Service %v doesn't exist; it won't be given a serving certificate", p.Service)
+	} else if err != nil {
+		return err
+	} else if service.Annotations[servingCertSecretAnnotation] == "" {
+		patch := client.MergeFrom(service.DeepCopy())
+		if service.Annotations == nil {
+			service.Annotations = map[string]string{}
+		}
+		service.Annotations[servingCertSecretAnnotation] = p.Service + "-tls"
+		if err := kubeclient.Patch(ctx, service, patch); err != nil {
+			return err
+		}
+	}
+
+	// The cookie secret is generated once; regenerating it would log out every user.
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: oauthConfigSecretName(p), Namespace: openshift.Namespace}}
+	_, err = controllerutil.CreateOrUpdate(ctx, kubeclient, secret, func() error {
+		openshift.setOwnershipLabels(secret)
+		if len(secret.Data["cookie_secret"]) > 0 {
+			return nil
+		}
+		cookie := make([]byte, 24)
+		if _, err := cryptorand.Read(cookie); err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{"cookie_secret": []byte(base64.StdEncoding.EncodeToString(cookie))}
+		return nil
+	})
+	return err
+}
+
+// oauthConfigSecretName returns the name of the secret holding the cookie secret of the OAuth proxy.
+func oauthConfigSecretName(p openshiftplugin.OAuthProxy) string {
+	return p.ServiceAccount + "-oauth-config"
+}
+
+func internalError(err error) error {
+	return &kfapisv3.KfError{
+		Code:    int(kfapisv3.INTERNAL_ERROR),
+		Message: fmt.Sprintf("%+v", err),
+	}
+}
@@ -0,0 +1,305 @@
+package openshift
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig/openshiftplugin"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/utils"
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+const testNamespace = "opendatahub"
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("could not build scheme; %v", err)
+	}
+	if err := routev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("could not build scheme; %v", err)
+	}
+	return scheme
+}
+
+func newTestProxy() *unstructured.Unstructured {
+	proxy := &unstructured.Unstructured{}
+	proxy.SetGroupVersionKind(proxyGVK)
+	proxy.SetName(clusterProxyName)
+	unstructured.SetNestedStringMap(proxy.Object, map[string]string{
+		"httpProxy":  "http://proxy.example.com:3128",
+		"httpsProxy": "http://proxy.example.com:3128",
+		"noProxy":    ".cluster.local,.svc",
+	}, "status")
+	return proxy
+}
+
+// TestApply sets up a namespace against envtest with the OpenShift CRDs in testdata/crds loaded.
+// It's skipped if the envtest binaries aren't installed.
+func TestApply(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("KUBEBUILDER_ASSETS isn't set")
+	}
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("testdata", "crds")},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("could not start envtest; %v", err)
+	}
+	defer testEnv.Stop()
+
+	kubeclient, err := client.New(cfg, client.Options{Scheme: newTestScheme(t)})
+	if err != nil {
+		t.Fatalf("could not create client; %v", err)
+	}
+	ctx := context.TODO()
+	for _, o := range []client.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}},
+		newTestProxy(),
+	} {
+		if err := kubeclient.Create(ctx, o); err != nil {
+			t.Fatalf("could not create %v; %v", o.GetName(), err)
+		}
+	}
+	testApply(t, kubeclient)
+}
+
+// TestApplyFakeClient runs the same checks against a fake client.
+func TestApplyFakeClient(t *testing.T) {
+	scheme := newTestScheme(t)
+	scheme.AddKnownTypeWithName(proxyGVK, &unstructured.Unstructured{})
+	kubeclient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newTestProxy()).Build()
+	testApply(t, kubeclient)
+}
+
+func testApply(t *testing.T, kubeclient client.Client) {
+	ctx := context.TODO()
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "dashboard", Namespace: testNamespace},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "https", Port: 8443, TargetPort: intstr.FromInt(8443)}},
+		},
+	}
+	if err := kubeclient.Create(ctx, service); err != nil {
+		t.Fatalf("could not create service; %v", err)
+	}
+
+	kfdef := &kfconfig.KfConfig{}
+	kfdef.Name = "opendatahub"
+	kfdef.Namespace = testNamespace
+	kfdef.SetPluginSpec(kfconfig.OPENSHIFT_PLUGIN_KIND, &openshiftplugin.OpenShiftPluginSpec{
+		SecurityContextConstraints: []openshiftplugin.SCCBinding{
+			{Name: "anyuid", ServiceAccounts: []string{"dashboard", "notebooks"}},
+		},
+		Routes: []openshiftplugin.Route{
+			{Name: "dashboard", TargetPort: "https", TLSTermination: "reencrypt"},
+		},
+		ClusterProxy: &openshiftplugin.ClusterProxy{},
+		OAuthProxies: []openshiftplugin.OAuthProxy{
+			{ServiceAccount: "dashboard", Route: "dashboard", Service: "dashboard"},
+		},
+	})
+	openshift := &OpenShift{KfConfig: kfdef, client: kubeclient}
+
+	// Applying twice checks the setup is idempotent, e.g. the cookie secret isn't regenerated.
+	var cookie []byte
+	for i := 0; i < 2; i++ {
+		if err := openshift.ApplyK8S(); err != nil {
+			t.Fatalf("could not apply; %v", err)
+		}
+		secret := &corev1.Secret{}
+		if err := kubeclient.Get(ctx, types.NamespacedName{Name: "dashboard-oauth-config", Namespace: testNamespace}, secret); err != nil {
+			t.Fatalf("could not get the cookie secret; %v", err)
+		}
+		if len(secret.Data["cookie_secret"]) == 0 || (cookie != nil && string(cookie) != string(secret.Data["cookie_secret"])) {
+			t.Fatalf("cookie secret; got %q after %q", secret.Data["cookie_secret"], cookie)
+		}
+		cookie = secret.Data["cookie_secret"]
+	}
+
+	binding := &rbacv1.RoleBinding{}
+	if err := kubeclient.Get(ctx, types.NamespacedName{Name: "opendatahub-scc-anyuid", Namespace: testNamespace}, binding); err != nil {
+		t.Fatalf("could not get the SCC role binding; %v", err)
+	}
+	expectedSubjects := []rbacv1.Subject{
+		{Kind: rbacv1.ServiceAccountKind, Name: "dashboard", Namespace: testNamespace},
+		{Kind: rbacv1.ServiceAccountKind, Name: "notebooks", Namespace: testNamespace},
+	}
+	if binding.RoleRef.Name != "opendatahub-scc-anyuid" || !reflect.DeepEqual(binding.Subjects, expectedSubjects) {
+		t.Errorf("SCC role binding; got %v, %v", binding.RoleRef, binding.Subjects)
+	}
+	role := &rbacv1.ClusterRole{}
+	if err := kubeclient.Get(ctx, types.NamespacedName{Name: "opendatahub-scc-anyuid"}, role); err != nil {
+		t.Fatalf("could not get the SCC cluster role; %v", err)
+	}
+	if len(role.Rules) != 1 || !reflect.DeepEqual(role.Rules[0].ResourceNames, []string{"anyuid"}) {
+		t.Errorf("SCC cluster role rules; got %v", role.Rules)
+	}
+
+	route := &routev1.Route{}
+	if err := kubeclient.Get(ctx, types.NamespacedName{Name: "dashboard", Namespace: testNamespace}, route); err != nil {
+		t.Fatalf("could not get the route; %v", err)
+	}
+	if route.Spec.To.Name != "dashboard" || route.Spec.Port == nil || route.Spec.Port.TargetPort != intstr.FromString("https") ||
+		route.Spec.TLS == nil || route.Spec.TLS.Termination != routev1.TLSTerminationReencrypt {
+		t.Errorf("route spec; got %+v", route.Spec)
+	}
+
+	proxyConfigMap := &corev1.ConfigMap{}
+	if err := kubeclient.Get(ctx, types.NamespacedName{Name: "cluster-proxy", Namespace: testNamespace}, proxyConfigMap); err != nil {
+		t.Fatalf("could not get the proxy ConfigMap; %v", err)
+	}
+	expectedEnv := map[string]string{
+		"HTTP_PROXY":  "http://proxy.example.com:3128",
+		"HTTPS_PROXY": "http://proxy.example.com:3128",
+		"NO_PROXY":    ".cluster.local,.svc",
+	}
+	if !reflect.DeepEqual(proxyConfigMap.Data, expectedEnv) {
+		t.Errorf("proxy ConfigMap; got %v; want %v", proxyConfigMap.Data, expectedEnv)
+	}
+	caConfigMap := &corev1.ConfigMap{}
+	if err := kubeclient.Get(ctx, types.NamespacedName{Name: "trusted-cabundle", Namespace: testNamespace}, caConfigMap); err != nil {
+		t.Fatalf("could not get the trusted CA ConfigMap; %v", err)
+	}
+	if caConfigMap.Labels[injectTrustedCABundleLabel] != "true" {
+		t.Errorf("trusted CA ConfigMap labels; got %v", caConfigMap.Labels)
+	}
+
+	sa := &corev1.ServiceAccount{}
+	if err := kubeclient.Get(ctx, types.NamespacedName{Name: "dashboard", Namespace: testNamespace}, sa); err != nil {
+		t.Fatalf("could not get the service account; %v", err)
+	}
+	if sa.Annotations[oauthRedirectReferenceAnnotation+"dashboard"] == "" {
+		t.Errorf("service account annotations; got %v", sa.Annotations)
+	}
+	if sa.Labels[utils.KfDefNameLabel] != "opendatahub" || sa.Labels[utils.KfDefApplicationLabel] != kftypesv3.OPENSHIFT {
+		t.Errorf("service account labels; got %v", sa.Labels)
+	}
+	if err := kubeclient.Get(ctx, types.NamespacedName{Name: "dashboard", Namespace: testNamespace}, service); err != nil {
+		t.Fatalf("could not get the service; %v", err)
+	}
+	if service.Annotations[servingCertSecretAnnotation] != "dashboard-tls" {
+		t.Errorf("service annotations; got %v", service.Annotations)
+	}
+}
+
+func TestApplyInvalidSpec(t *testing.T) {
+	testCases := map[string]*openshiftplugin.OpenShiftPluginSpec{
+		"invalid tlsTermination": {
+			Routes: []openshiftplugin.Route{{Name: "dashboard", TLSTermination: "none"}},
+		},
+		"privileged SCC": {
+			SecurityContextConstraints: []openshiftplugin.SCCBinding{{Name: "privileged", ServiceAccounts: []string{"notebooks"}}},
+		},
+	}
+	for name, spec := range testCases {
+		kfdef := &kfconfig.KfConfig{}
+		kfdef.SetPluginSpec(kfconfig.OPENSHIFT_PLUGIN_KIND, spec)
+		kubeclient := fake.NewClientBuilder().Build()
+		openshift := &OpenShift{KfConfig: kfdef, client: kubeclient}
+		err := openshift.ApplyK8S()
+		if kfErr, ok := err.(*kfapisv3.KfError); !ok || kfErr.Code != int(kfapisv3.INVALID_ARGUMENT) {
+			t.Errorf("%v: expected an invalid argument error; got %v", name, err)
+		}
+		roles := &rbacv1.ClusterRoleList{}
+		if err := kubeclient.List(context.TODO(), roles); err != nil || len(roles.Items) != 0 {
+			t.Errorf("%v: expected no cluster roles; got %v (%v)", name, roles.Items, err)
+		}
+	}
+}
+
+func TestDeleteFakeClient(t *testing.T) {
+	scheme := newTestScheme(t)
+	scheme.AddKnownTypeWithName(proxyGVK, &unstructured.Unstructured{})
+	// Another KfDef binding the same SCC keeps its cluster role from being deleted.
+	sharedBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "opendatahub-scc-nonroot", Namespace: "other"},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "opendatahub-scc-nonroot"},
+	}
+	// A service account created by an application isn't owned by the KfDef.
+	appServiceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "notebooks", Namespace: testNamespace}}
+	kubeclient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newTestProxy(), sharedBinding, appServiceAccount).Build()
+	ctx := context.TODO()
+
+	kfdef := &kfconfig.KfConfig{}
+	kfdef.Name = "opendatahub"
+	kfdef.Namespace = testNamespace
+	spec := &openshiftplugin.OpenShiftPluginSpec{
+		SecurityContextConstraints: []openshiftplugin.SCCBinding{
+			{Name: "anyuid", ServiceAccounts: []string{"dashboard"}},
+			{Name: "nonroot", ServiceAccounts: []string{"notebooks"}},
+		},
+		Routes: []openshiftplugin.Route{
+			{Name: "dashboard"},
+			{Name: "removed"},
+		},
+		ClusterProxy: &openshiftplugin.ClusterProxy{},
+		OAuthProxies: []openshiftplugin.OAuthProxy{
+			{ServiceAccount: "dashboard", Route: "dashboard", Service: "dashboard"},
+			{ServiceAccount: "notebooks", Route: "dashboard", Service: "dashboard"},
+		},
+	}
+	kfdef.SetPluginSpec(kfconfig.OPENSHIFT_PLUGIN_KIND, spec)
+	openshift := &OpenShift{KfConfig: kfdef, client: kubeclient}
+	if err := openshift.ApplyK8S(); err != nil {
+		t.Fatalf("could not apply; %v", err)
+	}
+
+	route := &routev1.Route{}
+	if err := kubeclient.Get(ctx, types.NamespacedName{Name: "removed", Namespace: testNamespace}, route); err != nil {
+		t.Fatalf("could not get the route; %v", err)
+	}
+	if route.Labels[utils.KfDefNameLabel] != "opendatahub" {
+		t.Errorf("route labels; got %v", route.Labels)
+	}
+
+	// Resources no longer in the spec are found by their labels.
+	spec.Routes = spec.Routes[:1]
+	kfdef.SetPluginSpec(kfconfig.OPENSHIFT_PLUGIN_KIND, spec)
+	if err := openshift.DeleteK8S(); err != nil {
+		t.Fatalf("could not delete; %v", err)
+	}
+
+	type testCase struct {
+		obj      client.Object
+		key      types.NamespacedName
+		expected bool
+	}
+	testCases := []testCase{
+		{obj: &rbacv1.RoleBinding{}, key: types.NamespacedName{Name: "opendatahub-scc-anyuid", Namespace: testNamespace}},
+		{obj: &rbacv1.ClusterRole{}, key: types.NamespacedName{Name: "opendatahub-scc-anyuid"}},
+		{obj: &rbacv1.ClusterRole{}, key: types.NamespacedName{Name: "opendatahub-scc-nonroot"}, expected: true},
+		{obj: &routev1.Route{}, key: types.NamespacedName{Name: "dashboard", Namespace: testNamespace}},
+		{obj: &routev1.Route{}, key: types.NamespacedName{Name: "removed", Namespace: testNamespace}},
+		{obj: &corev1.ConfigMap{}, key: types.NamespacedName{Name: "cluster-proxy", Namespace: testNamespace}},
+		{obj: &corev1.ConfigMap{}, key: types.NamespacedName{Name: "trusted-cabundle", Namespace: testNamespace}},
+		{obj: &corev1.Secret{}, key: types.NamespacedName{Name: "dashboard-oauth-config", Namespace: testNamespace}},
+		{obj: &corev1.ServiceAccount{}, key: types.NamespacedName{Name: "dashboard", Namespace: testNamespace}},
+		{obj: &corev1.ServiceAccount{}, key: types.NamespacedName{Name: "notebooks", Namespace: testNamespace}, expected: true},
+	}
+	for _, c := range testCases {
+		err := kubeclient.Get(ctx, c.key, c.obj)
+		if exists := err == nil; exists != c.expected {
+			t.Errorf("%T %v exists; got %v (%v); want %v", c.obj, c.key, exists, err, c.expected)
+		}
+	}
+}
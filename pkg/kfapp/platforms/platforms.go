@@ -0,0 +1,102 @@
+// Package platforms is a registry of the platforms, e.g. gcp or aws, a KfApp can be deployed to.
+// Platforms register themselves at init time keyed by the kind of their plugin, so which
+// platforms are available is decided at build time by the platform packages linked in.
+package platforms
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
+	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+)
+
+// K8SPlatform is implemented by platforms setting up resources of the cluster along with the
+// applications, e.g. OpenShift routes to their services. Unlike the platform itself, they are
+// applied and deleted with the K8S resources, e.g. on every reconcile of the operator, so they
+// mustn't provision infrastructure. ApplyK8S is called once the applications are applied and
+// DeleteK8S once they are deleted.
+type K8SPlatform interface {
+	ApplyK8S() error
+	DeleteK8S() error
+}
+
+// Factory creates the platform of a KfConfig.
+type Factory func(kfdef *kfconfig.KfConfig) (kftypesv3.Platform, error)
+
+type platform struct {
+	name    string
+	factory Factory
+}
+
+var (
+	mu        sync.RWMutex
+	platforms = map[kfconfig.PluginKindType]platform{}
+)
+
+// Register makes a platform available for the plugin kind under name, the value of
+// KfConfig.Spec.Platform selecting it. It panics if the kind or the name is already registered.
+func Register(kind kfconfig.PluginKindType, name string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+	if f == nil {
+		panic("platforms: Register factory is nil")
+	}
+	if _, dup := platforms[kind]; dup {
+		panic(fmt.Sprintf("platforms: Register called twice for plugin kind %v", kind))
+	}
+	if _, ok := kindOf(name); ok {
+		panic(fmt.Sprintf("platforms: Register called twice for platform %v", name))
+	}
+	platforms[kind] = platform{name: name, factory: f}
+}
+
+// GetPlatform returns the platform selected by KfConfig.Spec.Platform, or nil if no platform is set.
+// It returns an error if the platform isn't registered.
+func GetPlatform(kfdef *kfconfig.KfConfig) (kftypesv3.Platform, error) {
+	if kfdef.Spec.Platform == "" {
+		return nil, nil
+	}
+	mu.RLock()
+	kind, ok := kindOf(kfdef.Spec.Platform)
+	p := platforms[kind]
+	mu.RUnlock()
+	if !ok {
+		return nil, &kfapis.KfError{
+			Code: int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("unrecognized platform %v; registered platforms are %v",
+				kfdef.Spec.Platform, Names()),
+		}
+	}
+	return p.factory(kfdef)
+}
+
+// Kind returns the plugin kind of the platform registered under name.
+func Kind(name string) (kfconfig.PluginKindType, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return kindOf(name)
+}
+
+// Names returns the names of the registered platforms, sorted.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	var list []string
+	for _, p := range platforms {
+		list = append(list, p.name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func kindOf(name string) (kfconfig.PluginKindType, bool) {
+	for kind, p := range platforms {
+		if p.name == name {
+			return kind, true
+		}
+	}
+	return "", false
+}
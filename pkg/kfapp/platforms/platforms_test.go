@@ -0,0 +1,75 @@
+package platforms
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+)
+
+type fakePlatform struct {
+	kftypesv3.Platform
+	name string
+}
+
+func TestGetPlatform(t *testing.T) {
+	for _, name := range []string{"test-b", "test-a"} {
+		name := name
+		Register(kfconfig.PluginKindType("Kf"+name), name, func(kfdef *kfconfig.KfConfig) (kftypesv3.Platform, error) {
+			return &fakePlatform{name: name}, nil
+		})
+	}
+
+	type testCase struct {
+		platform     string
+		expectedName string
+		expectedErr  string
+	}
+
+	testCases := []testCase{
+		{platform: "test-a", expectedName: "test-a"},
+		{platform: "test-b", expectedName: "test-b"},
+		{platform: ""},
+		{platform: "other", expectedErr: "unrecognized platform other"},
+	}
+
+	for _, c := range testCases {
+		kfdef := &kfconfig.KfConfig{Spec: kfconfig.KfConfigSpec{Platform: c.platform}}
+		p, err := GetPlatform(kfdef)
+		if c.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expectedErr) {
+				t.Errorf("GetPlatform(%v); expected error containing %q; got %v", c.platform, c.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("GetPlatform(%v); unexpected error %v", c.platform, err)
+			continue
+		}
+		if c.expectedName == "" {
+			if p != nil {
+				t.Errorf("GetPlatform(%v); expected no platform; got %v", c.platform, p)
+			}
+			continue
+		}
+		if f, ok := p.(*fakePlatform); !ok || f.name != c.expectedName {
+			t.Errorf("GetPlatform(%v); got %v; want %v", c.platform, p, c.expectedName)
+		}
+	}
+
+	if kind, ok := Kind("test-a"); !ok || kind != "Kftest-a" {
+		t.Errorf("Kind(test-a); got %v, %v", kind, ok)
+	}
+	if names := Names(); !reflect.DeepEqual(names, []string{"test-a", "test-b"}) {
+		t.Errorf("Names(); got %v", names)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a platform twice to panic")
+		}
+	}()
+	Register("KfOther", "test-a", func(kfdef *kfconfig.KfConfig) (kftypesv3.Platform, error) { return nil, nil })
+}
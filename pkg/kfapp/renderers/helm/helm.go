@@ -0,0 +1,246 @@
+// Package helm renders applications setting a HelmConfig from their Helm chart.
+package helm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	log "github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/release"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// defaultValuesKey is the key read from the objects listed in HelmConfig.ValuesFrom if none is given.
+const defaultValuesKey = "values.yaml"
+
+func init() {
+	renderers.Register("helm", helmRenderer{})
+}
+
+// helmRenderer renders applications setting a HelmConfig from their chart.
+type helmRenderer struct{}
+
+func (helmRenderer) Handles(app kfconfig.Application) bool {
+	return app.HelmConfig != nil
+}
+
+func (helmRenderer) Render(config *kfconfig.KfConfig, app kfconfig.Application) ([]*unstructured.Unstructured, error) {
+	helm := app.HelmConfig
+	chart := helm.Chart
+	if helm.RepoRef != nil {
+		repoCache, ok := config.GetRepoCache(helm.RepoRef.Name)
+		if !ok {
+			return nil, &kfapisv3.KfError{
+				Code:    int(kfapisv3.INTERNAL_ERROR),
+				Message: fmt.Sprintf("application %v refers to repo %v which wasn't found in KfDef.Status.ReposCache", app.Name, helm.RepoRef.Name),
+			}
+		}
+		chart = path.Join(repoCache.LocalPath, helm.RepoRef.Path)
+	}
+	if chart == "" {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("application %v must set either HelmConfig.RepoRef or HelmConfig.Chart", app.Name),
+		}
+	}
+
+	// Values files are kept out of the kustomize packages as Secrets may provide them.
+	valuesDir := path.Join(config.Spec.AppDir, ".helm", "values", app.Name)
+	if err := os.MkdirAll(valuesDir, 0700); err != nil {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't create directory %v: %v", valuesDir, err),
+		}
+	}
+	var coreClient corev1.CoreV1Interface
+	if len(helm.ValuesFrom) > 0 {
+		var err error
+		if coreClient, err = corev1.NewForConfig(kftypesv3.GetConfig()); err != nil {
+			return nil, &kfapisv3.KfError{
+				Code:    int(kfapisv3.INTERNAL_ERROR),
+				Message: fmt.Sprintf("couldn't get core/v1 client: %v", err),
+			}
+		}
+	}
+	valuesFiles, err := writeHelmValues(coreClient, config.Namespace, helm, valuesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("Rendering chart %v for application %v", chart, app.Name)
+	out, err := renderChart(path.Join(config.Spec.AppDir, ".helm"), app.Name, config.Namespace, chart, helm, valuesFiles)
+	if err != nil {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't render chart %v for application %v: %v", chart, app.Name, err),
+		}
+	}
+	objects, err := renderers.DecodeObjects(out)
+	if err != nil {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't parse chart %v for application %v: %v", chart, app.Name, err),
+		}
+	}
+	return objects, nil
+}
+
+// renderChart renders the chart as the release in the namespace with the Helm SDK, the way
+// helm template --skip-tests does, including its CRDs and hooks. The hooks are applied along with
+// the other resources rather than at their lifecycle events, the helm.sh/hook annotations left
+// as they are; the test hooks, only run by helm test, are skipped. Charts from a chart
+// repository are downloaded to the cache of helmHome.
+func renderChart(helmHome string, release string, namespace string, chart string, helm *kfconfig.HelmConfig, valuesFiles []string) ([]byte, error) {
+	settings := cli.New()
+	settings.RepositoryCache = path.Join(helmHome, "cache", "repository")
+	settings.RepositoryConfig = path.Join(helmHome, "config", "repositories.yaml")
+	settings.RegistryConfig = path.Join(helmHome, "config", "registry.json")
+
+	install := action.NewInstall(&action.Configuration{})
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.IncludeCRDs = true
+	install.ReleaseName = release
+	install.Namespace = namespace
+	if helm.RepoRef == nil {
+		install.RepoURL = helm.ChartRepo
+		install.Version = helm.Version
+	}
+
+	chartPath, err := install.LocateChart(chart, settings)
+	if err != nil {
+		return nil, err
+	}
+	chrt, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	vals, err := (&values.Options{ValueFiles: valuesFiles}).MergeValues(getter.All(settings))
+	if err != nil {
+		return nil, err
+	}
+	rel, err := install.Run(chrt, vals)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString(rel.Manifest)
+	for _, hook := range rel.Hooks {
+		if isTestHook(hook) {
+			continue
+		}
+		fmt.Fprintf(out, "---\n# Source: %v\n%v\n", hook.Path, hook.Manifest)
+	}
+	return out.Bytes(), nil
+}
+
+// isTestHook returns true if the hook runs on helm test.
+func isTestHook(hook *release.Hook) bool {
+	for _, event := range hook.Events {
+		if event == release.HookTest {
+			return true
+		}
+	}
+	return false
+}
+
+// writeHelmValues writes the values read from HelmConfig.ValuesFrom, followed by HelmConfig.Values,
+// to files in dir and returns their paths in the order Helm should merge them.
+func writeHelmValues(coreClient corev1.CoreV1Interface, namespace string, helm *kfconfig.HelmConfig, dir string) ([]string, error) {
+	var files []string
+	for i, ref := range helm.ValuesFrom {
+		values, err := readHelmValues(coreClient, namespace, ref)
+		if err != nil {
+			return nil, err
+		}
+		if values == nil {
+			continue
+		}
+		f := path.Join(dir, fmt.Sprintf("values-from-%v.yaml", i))
+		if err := ioutil.WriteFile(f, values, 0600); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	if helm.Values != nil && len(helm.Values.Raw) > 0 {
+		f := path.Join(dir, "values.yaml")
+		if err := ioutil.WriteFile(f, helm.Values.Raw, 0600); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// readHelmValues returns the values held by the ConfigMap or Secret key, or nil if it is missing
+// and the reference is optional.
+func readHelmValues(coreClient corev1.CoreV1Interface, namespace string, ref kfconfig.ValuesReference) ([]byte, error) {
+	key := ref.Key
+	if key == "" {
+		key = defaultValuesKey
+	}
+
+	var values []byte
+	var found bool
+	var err error
+	switch ref.Kind {
+	case kfconfig.ConfigMapKind:
+		var cm *v1.ConfigMap
+		cm, err = coreClient.ConfigMaps(namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+		if err == nil {
+			var s string
+			if s, found = cm.Data[key]; found {
+				values = []byte(s)
+			} else {
+				values, found = cm.BinaryData[key]
+			}
+		}
+	case kfconfig.SecretKind:
+		var secret *v1.Secret
+		secret, err = coreClient.Secrets(namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+		if err == nil {
+			values, found = secret.Data[key]
+		}
+	default:
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("invalid kind %v for values of %v; expected ConfigMap or Secret", ref.Kind, ref.Name),
+		}
+	}
+
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't read %v %v/%v: %v", ref.Kind, namespace, ref.Name, err),
+		}
+	}
+	if !found {
+		if ref.Optional {
+			return nil, nil
+		}
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't find key %v in %v %v/%v", key, ref.Kind, namespace, ref.Name),
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,241 @@
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// writeTestChart writes a chart with a CRD, a template using the values, a hook and a test hook to dir.
+func writeTestChart(t *testing.T, dir string) {
+	files := map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: vendor
+version: 1.0.0
+`,
+		"values.yaml": `replicas: 1
+`,
+		"crds/crd.yaml": `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+`,
+		"templates/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+  namespace: {{ .Release.Namespace }}
+spec:
+  replicas: {{ .Values.replicas }}
+`,
+		"templates/hook.yaml": `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ .Release.Name }}-migrate
+  annotations:
+    helm.sh/hook: pre-install
+`,
+		"templates/tests/test.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ .Release.Name }}-test
+  annotations:
+    helm.sh/hook: test
+`,
+	}
+	for name, content := range files {
+		f := path.Join(dir, name)
+		if err := os.MkdirAll(path.Dir(f), 0755); err != nil {
+			t.Fatalf("couldn't create chart directory: %v", err)
+		}
+		if err := ioutil.WriteFile(f, []byte(content), 0644); err != nil {
+			t.Fatalf("couldn't write chart file: %v", err)
+		}
+	}
+}
+
+func TestHelmRenderer(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+	writeTestChart(t, path.Join(testDir, "manifests", "charts/vendor"))
+
+	type testCase struct {
+		name        string
+		helm        *kfconfig.HelmConfig
+		expected    []string
+		unexpected  []string
+		expectedErr bool
+	}
+
+	testCases := []testCase{
+		{
+			name: "repo",
+			helm: &kfconfig.HelmConfig{
+				RepoRef: &kfconfig.RepoRef{Name: "manifests", Path: "charts/vendor"},
+				Values:  &runtime.RawExtension{Raw: []byte(`{"replicas":2}`)},
+			},
+			expected: []string{
+				"kind: CustomResourceDefinition",
+				"name: vendor\n  namespace: opendatahub\nspec:\n  replicas: 2",
+				"name: vendor-migrate",
+			},
+			unexpected: []string{
+				"name: vendor-test",
+			},
+		},
+		{
+			name: "chart",
+			helm: &kfconfig.HelmConfig{Chart: path.Join(testDir, "manifests", "charts/vendor")},
+			expected: []string{
+				"replicas: 1",
+			},
+		},
+		{
+			name:        "missing-chart",
+			helm:        &kfconfig.HelmConfig{Version: "1.0.0"},
+			expectedErr: true,
+		},
+		{
+			name:        "missing-repo",
+			helm:        &kfconfig.HelmConfig{RepoRef: &kfconfig.RepoRef{Name: "other"}},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid-chart",
+			helm:        &kfconfig.HelmConfig{RepoRef: &kfconfig.RepoRef{Name: "manifests", Path: "charts/missing"}},
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range testCases {
+		appDir := path.Join(testDir, c.name)
+		config := &kfconfig.KfConfig{
+			Spec: kfconfig.KfConfigSpec{AppDir: appDir},
+			Status: kfconfig.Status{
+				Caches: []kfconfig.Cache{{Name: "manifests", LocalPath: path.Join(testDir, "manifests")}},
+			},
+		}
+		config.Namespace = "opendatahub"
+		app := kfconfig.Application{Name: "vendor", HelmConfig: c.helm}
+		if !(helmRenderer{}).Handles(app) {
+			t.Fatalf("%v: expected the helm renderer to handle the application", c.name)
+		}
+
+		objects, err := helmRenderer{}.Render(config, app)
+		if c.expectedErr {
+			if err == nil {
+				t.Fatalf("%v: expected an error", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: could not generate application; %v", c.name, err)
+		}
+		resources, err := renderers.EncodeObjects(objects)
+		if err != nil {
+			t.Fatalf("%v: could not encode resources; %v", c.name, err)
+		}
+		for _, expected := range c.expected {
+			if !strings.Contains(string(resources), expected) {
+				t.Errorf("%v: expected %q in rendered resources; got %v", c.name, expected, string(resources))
+			}
+		}
+		for _, unexpected := range c.unexpected {
+			if strings.Contains(string(resources), unexpected) {
+				t.Errorf("%v: unexpected %q in rendered resources; got %v", c.name, unexpected, string(resources))
+			}
+		}
+	}
+}
+
+func TestWriteHelmValues(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	kubeClient := fake.NewSimpleClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "defaults", Namespace: "opendatahub"},
+			Data:       map[string]string{"values.yaml": "replicas: 1\n"},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "opendatahub"},
+			Data:       map[string][]byte{"credentials.yaml": []byte("password: secret\n")},
+		},
+	)
+
+	type testCase struct {
+		name           string
+		helm           *kfconfig.HelmConfig
+		expectedValues []string
+		expectedErr    string
+	}
+
+	testCases := []testCase{
+		{
+			name: "ordered",
+			helm: &kfconfig.HelmConfig{
+				ValuesFrom: []kfconfig.ValuesReference{
+					{Kind: "ConfigMap", Name: "defaults"},
+					{Kind: "Secret", Name: "credentials", Key: "credentials.yaml"},
+					{Kind: "ConfigMap", Name: "missing", Optional: true},
+				},
+				Values: &runtime.RawExtension{Raw: []byte(`{"replicas":2}`)},
+			},
+			expectedValues: []string{"replicas: 1\n", "password: secret\n", `{"replicas":2}`},
+		},
+		{
+			name: "missing",
+			helm: &kfconfig.HelmConfig{
+				ValuesFrom: []kfconfig.ValuesReference{{Kind: "ConfigMap", Name: "missing"}},
+			},
+			expectedErr: "couldn't find key values.yaml",
+		},
+		{
+			name: "missing-key",
+			helm: &kfconfig.HelmConfig{
+				ValuesFrom: []kfconfig.ValuesReference{{Kind: "Secret", Name: "credentials"}},
+			},
+			expectedErr: "couldn't find key values.yaml",
+		},
+		{
+			name: "invalid-kind",
+			helm: &kfconfig.HelmConfig{
+				ValuesFrom: []kfconfig.ValuesReference{{Kind: "Pod", Name: "defaults"}},
+			},
+			expectedErr: "invalid kind",
+		},
+	}
+
+	for _, c := range testCases {
+		dir := path.Join(testDir, c.name)
+		os.MkdirAll(dir, os.ModePerm)
+		files, err := writeHelmValues(kubeClient.CoreV1(), "opendatahub", c.helm, dir)
+		if c.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expectedErr) {
+				t.Fatalf("%v: expected error containing %q; got %v", c.name, c.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: could not write values; %v", c.name, err)
+		}
+		var actual []string
+		for _, f := range files {
+			values, _ := ioutil.ReadFile(f)
+			actual = append(actual, string(values))
+		}
+		if !reflect.DeepEqual(actual, c.expectedValues) {
+			t.Fatalf("%v: values; got %q; want %q", c.name, actual, c.expectedValues)
+		}
+	}
+}
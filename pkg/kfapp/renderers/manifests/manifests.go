@@ -0,0 +1,122 @@
+// Package manifests renders applications setting a ManifestsConfig from their YAML manifests.
+package manifests
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	kfapisv3 "github.com/opendatahub-io/opendatahub-operator/apis"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// manifestsTemplateData is the data manifests are rendered with when ManifestsConfig.Template is set.
+type manifestsTemplateData struct {
+	Name       string
+	Namespace  string
+	Parameters map[string]string
+}
+
+func init() {
+	renderers.Register("manifests", manifestsRenderer{})
+}
+
+// manifestsRenderer renders applications setting a ManifestsConfig from their YAML manifests,
+// rendering them as templates if asked to.
+type manifestsRenderer struct{}
+
+func (manifestsRenderer) Handles(app kfconfig.Application) bool {
+	return app.ManifestsConfig != nil
+}
+
+func (manifestsRenderer) Render(config *kfconfig.KfConfig, app kfconfig.Application) ([]*unstructured.Unstructured, error) {
+	manifests := app.ManifestsConfig
+	if manifests.RepoRef == nil {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("application %v is missing ManifestsConfig.RepoRef", app.Name),
+		}
+	}
+	repoCache, ok := config.GetRepoCache(manifests.RepoRef.Name)
+	if !ok {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INTERNAL_ERROR),
+			Message: fmt.Sprintf("application %v refers to repo %v which wasn't found in KfDef.Status.ReposCache", app.Name, manifests.RepoRef.Name),
+		}
+	}
+	manifestsDir := path.Join(repoCache.LocalPath, manifests.RepoRef.Path)
+
+	params, err := config.ResolveParameters(manifests.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	data := manifestsTemplateData{
+		Name:       config.Name,
+		Namespace:  config.Namespace,
+		Parameters: map[string]string{},
+	}
+	for _, p := range params {
+		data.Parameters[p.Name] = p.Value
+	}
+
+	log.Infof("Reading manifests %v for application %v", manifestsDir, app.Name)
+	var resources []*unstructured.Unstructured
+	err = filepath.Walk(manifestsDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !(strings.HasSuffix(file, ".yaml") || strings.HasSuffix(file, ".yml")) {
+			return nil
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if manifests.Template {
+			if content, err = renderManifestTemplate(file, content, data); err != nil {
+				return err
+			}
+		}
+		objects, err := renderers.DecodeObjects(content)
+		if err != nil {
+			return fmt.Errorf("couldn't parse %v: %v", file, err)
+		}
+		resources = append(resources, objects...)
+		return nil
+	})
+	if err != nil {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't read manifests for application %v: %v", app.Name, err),
+		}
+	}
+	if len(resources) == 0 {
+		return nil, &kfapisv3.KfError{
+			Code:    int(kfapisv3.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("no manifests found in %v for application %v", manifestsDir, app.Name),
+		}
+	}
+
+	return resources, nil
+}
+
+// renderManifestTemplate renders a manifest with text/template. Referencing a missing parameter is an error.
+func renderManifestTemplate(name string, content []byte, data manifestsTemplateData) ([]byte, error) {
+	tmpl, err := template.New(path.Base(name)).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse template %v: %v", name, err)
+	}
+	out := &bytes.Buffer{}
+	if err := tmpl.Execute(out, data); err != nil {
+		return nil, fmt.Errorf("couldn't render template %v: %v", name, err)
+	}
+	return out.Bytes(), nil
+}
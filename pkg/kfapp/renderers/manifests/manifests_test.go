@@ -0,0 +1,105 @@
+package manifests
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfapp/renderers"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+)
+
+func TestManifestsRenderer(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	repoDir := path.Join(testDir, "manifests")
+	files := map[string]string{
+		"component/service.yaml": "apiVersion: v1\nkind: Service\nmetadata:\n  name: component\n",
+		"component/deployment/deployment.yml": "---\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n" +
+			"  name: component\n  namespace: {{ .Namespace }}\nspec:\n  replicas: {{ .Parameters.replicas }}\n",
+		"component/README.md":     "not a manifest",
+		"missing/deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nspec:\n  replicas: {{ .Parameters.replicas }}\n",
+		"empty/README.md":         "not a manifest",
+	}
+	for name, content := range files {
+		os.MkdirAll(path.Dir(path.Join(repoDir, name)), os.ModePerm)
+		ioutil.WriteFile(path.Join(repoDir, name), []byte(content), 0644)
+	}
+
+	type testCase struct {
+		name              string
+		manifests         *kfconfig.ManifestsConfig
+		expectedResources []string
+		expectedErr       string
+	}
+
+	testCases := []testCase{
+		{
+			name: "template",
+			manifests: &kfconfig.ManifestsConfig{
+				RepoRef:    &kfconfig.RepoRef{Name: "manifests", Path: "component"},
+				Template:   true,
+				Parameters: []kfconfig.NameValue{{Name: "replicas", Value: "2"}},
+			},
+			expectedResources: []string{
+				"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: component\n  namespace: opendatahub\nspec:\n  replicas: 2\n",
+				"apiVersion: v1\nkind: Service\nmetadata:\n  name: component\n",
+			},
+		},
+		{
+			name: "missing-parameter",
+			manifests: &kfconfig.ManifestsConfig{
+				RepoRef:  &kfconfig.RepoRef{Name: "manifests", Path: "missing"},
+				Template: true,
+			},
+			expectedErr: "couldn't render template",
+		},
+		{
+			name: "no-manifests",
+			manifests: &kfconfig.ManifestsConfig{
+				RepoRef: &kfconfig.RepoRef{Name: "manifests", Path: "empty"},
+			},
+			expectedErr: "no manifests found",
+		},
+		{
+			name:        "missing-repo-ref",
+			manifests:   &kfconfig.ManifestsConfig{},
+			expectedErr: "missing ManifestsConfig.RepoRef",
+		},
+	}
+
+	for _, c := range testCases {
+		appDir := path.Join(testDir, c.name)
+		config := &kfconfig.KfConfig{
+			Spec: kfconfig.KfConfigSpec{AppDir: appDir},
+			Status: kfconfig.Status{
+				Caches: []kfconfig.Cache{{Name: "manifests", LocalPath: repoDir}},
+			},
+		}
+		config.Name = "opendatahub"
+		config.Namespace = "opendatahub"
+		resources, err := manifestsRenderer{}.Render(config,
+			kfconfig.Application{Name: "component", ManifestsConfig: c.manifests})
+		if c.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expectedErr) {
+				t.Fatalf("%v: expected error containing %q; got %v", c.name, c.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: could not generate application; %v", c.name, err)
+		}
+
+		expected, err := renderers.DecodeObjects([]byte(strings.Join(c.expectedResources, "---\n")))
+		if err != nil {
+			t.Fatalf("%v: could not decode expected resources; %v", c.name, err)
+		}
+		if !reflect.DeepEqual(resources, expected) {
+			t.Fatalf("%v: resources; got %v; want %v", c.name, resources, expected)
+		}
+	}
+}
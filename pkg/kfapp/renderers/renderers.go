@@ -0,0 +1,108 @@
+// Package renderers is a registry of the renderers turning the source of an application,
+// e.g. a kustomize package or a Helm chart, into the resources of the application.
+// Renderers register themselves at init time, so new source types can be added without
+// changing the package managers using them.
+package renderers
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"github.com/opendatahub-io/opendatahub-operator/pkg/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Renderer renders an application into its resources.
+type Renderer interface {
+	// Handles returns true if the renderer renders the application, e.g. because the application
+	// sets the config of the renderer's source type.
+	Handles(app kfconfig.Application) bool
+	// Render returns the resources of the application.
+	Render(config *kfconfig.KfConfig, app kfconfig.Application) ([]*unstructured.Unstructured, error)
+}
+
+var (
+	mu        sync.RWMutex
+	renderers = map[string]Renderer{}
+)
+
+// Register makes a renderer available under name. It panics if name is already registered.
+func Register(name string, r Renderer) {
+	mu.Lock()
+	defer mu.Unlock()
+	if r == nil {
+		panic("renderers: Register renderer is nil")
+	}
+	if _, dup := renderers[name]; dup {
+		panic(fmt.Sprintf("renderers: Register called twice for renderer %v", name))
+	}
+	renderers[name] = r
+}
+
+// ForApplication returns the name of the renderer handling the application and the renderer.
+// It returns false if no registered renderer handles it. Renderers are tried in name order.
+func ForApplication(app kfconfig.Application) (string, Renderer, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, name := range names() {
+		if renderers[name].Handles(app) {
+			return name, renderers[name], true
+		}
+	}
+	return "", nil, false
+}
+
+// Names returns the names of the registered renderers, sorted.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return names()
+}
+
+func names() []string {
+	var list []string
+	for name := range renderers {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// DecodeObjects parses a YAML stream into objects, skipping empty documents.
+func DecodeObjects(data []byte) ([]*unstructured.Unstructured, error) {
+	docs, err := utils.SplitYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	var objects []*unstructured.Unstructured
+	for _, doc := range docs {
+		// Empty documents, e.g. a leading separator or a block removed by a template, decode to null.
+		if strings.TrimSpace(string(doc)) == "null" {
+			continue
+		}
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(doc, &obj.Object); err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// EncodeObjects returns the objects as a YAML stream.
+func EncodeObjects(objects []*unstructured.Unstructured) ([]byte, error) {
+	var docs [][]byte
+	for _, obj := range objects {
+		doc, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return bytes.Join(docs, []byte("---\n")), nil
+}
@@ -0,0 +1,78 @@
+package renderers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type fakeRenderer struct {
+	app string
+}
+
+func (f fakeRenderer) Handles(app kfconfig.Application) bool {
+	return app.Name == f.app
+}
+
+func (f fakeRenderer) Render(config *kfconfig.KfConfig, app kfconfig.Application) ([]*unstructured.Unstructured, error) {
+	return nil, nil
+}
+
+func TestForApplication(t *testing.T) {
+	Register("test-b", fakeRenderer{app: "b"})
+	Register("test-a", fakeRenderer{app: "a"})
+	Register("test-other-a", fakeRenderer{app: "a"})
+
+	type testCase struct {
+		app          string
+		expectedName string
+		expectedOk   bool
+	}
+
+	testCases := []testCase{
+		{app: "a", expectedName: "test-a", expectedOk: true},
+		{app: "b", expectedName: "test-b", expectedOk: true},
+		{app: "c", expectedName: "", expectedOk: false},
+	}
+
+	for _, c := range testCases {
+		name, _, ok := ForApplication(kfconfig.Application{Name: c.app})
+		if name != c.expectedName || ok != c.expectedOk {
+			t.Errorf("ForApplication(%v); got %v, %v; want %v, %v", c.app, name, ok, c.expectedName, c.expectedOk)
+		}
+	}
+
+	if names := Names(); !reflect.DeepEqual(names, []string{"test-a", "test-b", "test-other-a"}) {
+		t.Errorf("Names(); got %v", names)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a name twice to panic")
+		}
+	}()
+	Register("test-a", fakeRenderer{app: "a"})
+}
+
+func TestDecodeObjects(t *testing.T) {
+	data := "---\napiVersion: v1\nkind: Service\nmetadata:\n  name: component\n---\n" +
+		"apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: component\nspec:\n  replicas: 2\n"
+	objects, err := DecodeObjects([]byte(data))
+	if err != nil {
+		t.Fatalf("could not decode objects; %v", err)
+	}
+	if len(objects) != 2 || objects[0].GetKind() != "Service" || objects[1].GetKind() != "Deployment" {
+		t.Fatalf("objects; got %v", objects)
+	}
+
+	encoded, err := EncodeObjects(objects)
+	if err != nil {
+		t.Fatalf("could not encode objects; %v", err)
+	}
+	decoded, err := DecodeObjects(encoded)
+	if err != nil || !reflect.DeepEqual(decoded, objects) {
+		t.Fatalf("encoded objects; got %v, %v; want %v", decoded, err, objects)
+	}
+}
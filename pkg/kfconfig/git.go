@@ -0,0 +1,232 @@
+package kfconfig
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// gitForcePrefix can be used to force a URI to be fetched with git, following the go-getter convention
+	// e.g. git::https://github.com/opendatahub-io/odh-manifests
+	gitForcePrefix = "git::"
+)
+
+var commitSHARegexp = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// IsGitURI returns true if the repo URI should be fetched with git rather than downloaded as a tarball.
+func IsGitURI(uri string) bool {
+	if strings.HasPrefix(uri, gitForcePrefix) {
+		return true
+	}
+	for _, scheme := range []string{"git://", "ssh://", "git+ssh://", "git@"} {
+		if strings.HasPrefix(uri, scheme) {
+			return true
+		}
+	}
+	// Ignore any query or trailing slash when checking the extension e.g. https://host/repo.git/
+	p := strings.SplitN(uri, "?", 2)[0]
+	return strings.HasSuffix(strings.TrimSuffix(p, "/"), ".git")
+}
+
+// gitRemoteAndRef returns the remote and the ref to fetch of the repo, HEAD if it has no ref.
+// A remote or ref starting with a dash is rejected since git would parse it as an option,
+// e.g. --upload-pack running an arbitrary command.
+func gitRemoteAndRef(r Repo) (string, string, error) {
+	remote := strings.TrimPrefix(r.URI, gitForcePrefix)
+	ref := r.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	for _, arg := range []string{remote, ref} {
+		if strings.HasPrefix(arg, "-") {
+			return "", "", &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("invalid git repo %v: %q can't start with a dash", r.Name, arg),
+			}
+		}
+	}
+	return remote, ref, nil
+}
+
+// fetchGitRepo does a shallow fetch of the repo's ref and checks it out in dir.
+// An empty ref fetches the remote HEAD.
+// It returns the commit SHA that was checked out.
+func fetchGitRepo(r Repo, auth *repoAuth, dir string) (string, error) {
+	remote, ref, err := gitRemoteAndRef(r)
+	if err != nil {
+		return "", err
+	}
+
+	g, cleanup, err := newGitCmd(r, auth, dir)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	if err := g.run("init", "--quiet"); err != nil {
+		return "", err
+	}
+
+	if err := g.run("fetch", "--quiet", "--depth", "1", "--", remote, ref); err != nil {
+		// Not every server allows fetching an arbitrary commit by SHA so fall back to a full fetch.
+		if !commitSHARegexp.MatchString(ref) {
+			return "", err
+		}
+		log.Warnf("Shallow fetch of commit %v failed; fetching full history of %v", ref, remote)
+		if err := g.run("fetch", "--quiet", "--", remote); err != nil {
+			return "", err
+		}
+		if err := g.run("checkout", "--quiet", "--detach", ref, "--"); err != nil {
+			return "", err
+		}
+	} else if err := g.run("checkout", "--quiet", "--detach", "FETCH_HEAD", "--"); err != nil {
+		return "", err
+	}
+
+	out, err := g.output("rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return "", &kfapis.KfError{
+			Code:    int(kfapis.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't resolve the commit checked out from %v: %v", remote, err),
+		}
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// resolveGitRevision returns the commit SHA the repo's ref currently points to on the remote.
+func resolveGitRevision(r Repo, auth *repoAuth) (string, error) {
+	remote, ref, err := gitRemoteAndRef(r)
+	if err != nil {
+		return "", err
+	}
+	if commitSHARegexp.MatchString(ref) {
+		return ref, nil
+	}
+
+	g, cleanup, err := newGitCmd(r, auth, "")
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	out, err := g.output("ls-remote", "--", remote, ref)
+	if err != nil {
+		return "", &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("git ls-remote of %v failed: %v", remote, err),
+		}
+	}
+
+	// Annotated tags are listed twice; the peeled entry ending in ^{} has the commit.
+	revision := ""
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[1] {
+		case "refs/tags/" + ref + "^{}":
+			return fields[0], nil
+		case ref, "refs/heads/" + ref, "refs/tags/" + ref:
+			if revision == "" {
+				revision = fields[0]
+			}
+		}
+	}
+	if revision == "" {
+		return "", &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't find ref %v in %v", ref, remote),
+		}
+	}
+	return revision, nil
+}
+
+// newGitCmd returns a gitCmd running in dir with the proxy, credentials and timeout of the repo.
+// The returned cleanup function must be called once the commands are done.
+func newGitCmd(r Repo, auth *repoAuth, dir string) (*gitCmd, func(), error) {
+	ctx, cancel := context.Background(), func() {}
+	if r.Timeout != nil {
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout.Duration)
+	}
+	g := &gitCmd{
+		ctx: ctx,
+		dir: dir,
+	}
+	cleanup := cancel
+
+	// Credentials are passed through the environment rather than the command line
+	// so that they don't show up in the process list or in error messages.
+	if r.Proxy != "" {
+		g.config("http.proxy", r.Proxy)
+	}
+	if authorization := auth.authorization(); authorization != "" {
+		g.config("http.extraHeader", "Authorization: "+authorization)
+	}
+	if auth != nil && len(auth.caBundle) > 0 {
+		caFile, err := ioutil.TempFile("", "kf-ca-*.crt")
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		cleanup = func() {
+			cancel()
+			os.Remove(caFile.Name())
+		}
+		_, err = caFile.Write(auth.caBundle)
+		if closeErr := caFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		g.config("http.sslCAInfo", caFile.Name())
+	}
+	return g, cleanup, nil
+}
+
+// gitCmd runs git commands in a directory with extra configuration set through the environment.
+type gitCmd struct {
+	ctx  context.Context
+	dir  string
+	env  []string
+	keys int
+}
+
+func (g *gitCmd) config(key string, value string) {
+	g.env = append(g.env,
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=%v", g.keys, key),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=%v", g.keys, value))
+	g.keys++
+}
+
+func (g *gitCmd) command(args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(g.ctx, "git", args...)
+	cmd.Dir = g.dir
+	// Never block on a credentials prompt.
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", fmt.Sprintf("GIT_CONFIG_COUNT=%d", g.keys))
+	cmd.Env = append(cmd.Env, g.env...)
+	return cmd
+}
+
+func (g *gitCmd) run(args ...string) error {
+	if out, err := g.command(args...).CombinedOutput(); err != nil {
+		return &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("git %v failed: %v; %v", strings.Join(args, " "), err, strings.TrimSpace(string(out))),
+		}
+	}
+	return nil
+}
+
+func (g *gitCmd) output(args ...string) (string, error) {
+	out, err := g.command(args...).Output()
+	return string(out), err
+}
@@ -10,6 +10,7 @@ func maybeGetPlatform(pluginKind string) string {
 		string(kfconfig.AWS_PLUGIN_KIND):              kftypesv3.AWS,
 		string(kfconfig.GCP_PLUGIN_KIND):              kftypesv3.GCP,
 		string(kfconfig.EXISTING_ARRIKTO_PLUGIN_KIND): kftypesv3.EXISTING_ARRIKTO,
+		string(kfconfig.OPENSHIFT_PLUGIN_KIND):        kftypesv3.OPENSHIFT,
 	}
 
 	p, ok := platforms[pluginKind]
@@ -44,9 +44,26 @@ func (v V1) LoadKfConfig(def interface{}) (*kfconfig.KfConfig, error) {
 	config.Labels = kfdef.Labels
 	config.Annotations = kfdef.Annotations
 	config.Spec.Version = kfdef.Spec.Version
+	config.Spec.StrictParameters = kfdef.Spec.StrictParameters
+	config.Spec.Images = toKfConfigImages(kfdef.Spec.Images)
+	config.Spec.Patches = toKfConfigPatches(kfdef.Spec.Patches)
+	config.Spec.Proxy = toKfConfigProxy(kfdef.Spec.Proxy)
+	config.Spec.ImagePullSecrets = toKfConfigImagePullSecrets(kfdef.Spec.ImagePullSecrets)
+	config.Spec.ImagePullPolicy = kfdef.Spec.ImagePullPolicy
+	config.Spec.CommonLabels = kfdef.Spec.CommonLabels
+	config.Spec.CommonAnnotations = kfdef.Spec.CommonAnnotations
 	for _, app := range kfdef.Spec.Applications {
 		application := kfconfig.Application{
-			Name: app.Name,
+			Name:              app.Name,
+			Images:            toKfConfigImages(app.Images),
+			Patches:           toKfConfigPatches(app.Patches),
+			Placement:         toKfConfigPlacement(app.Placement),
+			ImagePullSecrets:  toKfConfigImagePullSecrets(app.ImagePullSecrets),
+			ImagePullPolicy:   app.ImagePullPolicy,
+			HardenPodSecurity: app.HardenPodSecurity,
+			CommonLabels:      app.CommonLabels,
+			CommonAnnotations: app.CommonAnnotations,
+			Resources:         app.Resources,
 		}
 		if app.KustomizeConfig != nil {
 			kconfig := &kfconfig.KustomizeConfig{
@@ -66,13 +83,56 @@ func (v V1) LoadKfConfig(def interface{}) (*kfconfig.KfConfig, error) {
 			}
 			for _, param := range app.KustomizeConfig.Parameters {
 				p := kfconfig.NameValue{
-					Name:  param.Name,
-					Value: param.Value,
+					Name:      param.Name,
+					Value:     param.Value,
+					ValueFrom: toKfConfigValueSource(param.ValueFrom),
 				}
 				kconfig.Parameters = append(kconfig.Parameters, p)
 			}
 			application.KustomizeConfig = kconfig
 		}
+		if app.HelmConfig != nil {
+			hconfig := &kfconfig.HelmConfig{
+				ChartRepo: app.HelmConfig.ChartRepo,
+				Chart:     app.HelmConfig.Chart,
+				Version:   app.HelmConfig.Version,
+				Values:    app.HelmConfig.Values,
+			}
+			if app.HelmConfig.RepoRef != nil {
+				hconfig.RepoRef = &kfconfig.RepoRef{
+					Name: app.HelmConfig.RepoRef.Name,
+					Path: app.HelmConfig.RepoRef.Path,
+				}
+			}
+			for _, ref := range app.HelmConfig.ValuesFrom {
+				hconfig.ValuesFrom = append(hconfig.ValuesFrom, kfconfig.ValuesReference{
+					Kind:     ref.Kind,
+					Name:     ref.Name,
+					Key:      ref.Key,
+					Optional: ref.Optional,
+				})
+			}
+			application.HelmConfig = hconfig
+		}
+		if app.ManifestsConfig != nil {
+			mconfig := &kfconfig.ManifestsConfig{
+				Template: app.ManifestsConfig.Template,
+			}
+			if app.ManifestsConfig.RepoRef != nil {
+				mconfig.RepoRef = &kfconfig.RepoRef{
+					Name: app.ManifestsConfig.RepoRef.Name,
+					Path: app.ManifestsConfig.RepoRef.Path,
+				}
+			}
+			for _, param := range app.ManifestsConfig.Parameters {
+				mconfig.Parameters = append(mconfig.Parameters, kfconfig.NameValue{
+					Name:      param.Name,
+					Value:     param.Value,
+					ValueFrom: toKfConfigValueSource(param.ValueFrom),
+				})
+			}
+			application.ManifestsConfig = mconfig
+		}
 		config.Spec.Applications = append(config.Spec.Applications, application)
 	}
 
@@ -124,14 +184,29 @@ func (v V1) LoadKfConfig(def interface{}) (*kfconfig.KfConfig, error) {
 				Name: secret.SecretSource.EnvSource.Name,
 			}
 		}
+		if ref := secret.SecretSource.SecretKeyRef; ref != nil {
+			src.SecretKeyRef = &kfconfig.ObjectKeyRef{Namespace: ref.Namespace, Name: ref.Name, Key: ref.Key}
+		}
+		if ref := secret.SecretSource.ConfigMapKeyRef; ref != nil {
+			src.ConfigMapKeyRef = &kfconfig.ObjectKeyRef{Namespace: ref.Namespace, Name: ref.Name, Key: ref.Key}
+		}
 		s.SecretSource = src
 		config.Spec.Secrets = append(config.Spec.Secrets, s)
 	}
 
 	for _, repo := range kfdef.Spec.Repos {
 		r := kfconfig.Repo{
-			Name: repo.Name,
-			URI:  repo.URI,
+			Name:         repo.Name,
+			URI:          repo.URI,
+			Ref:          repo.Ref,
+			Proxy:        repo.Proxy,
+			Timeout:      repo.Timeout,
+			PollInterval: repo.PollInterval,
+		}
+		if repo.SecretRef != nil {
+			r.SecretRef = &kfconfig.SecretRef{
+				Name: repo.SecretRef.Name,
+			}
 		}
 		config.Spec.Repos = append(config.Spec.Repos, r)
 	}
@@ -149,11 +224,13 @@ func (v V1) LoadKfConfig(def interface{}) (*kfconfig.KfConfig, error) {
 	}
 	for _, cache := range kfdef.Status.ReposCache {
 		c := kfconfig.Cache{
-			Name:      cache.Name,
-			LocalPath: cache.LocalPath,
+			Name:             cache.Name,
+			LocalPath:        cache.LocalPath,
+			ResolvedRevision: cache.ResolvedRevision,
 		}
 		config.Status.Caches = append(config.Status.Caches, c)
 	}
+	config.Status.RenderedKinds = kfdef.Status.RenderedKinds
 
 	return config, nil
 }
@@ -167,10 +244,27 @@ func (v V1) LoadKfDef(config kfconfig.KfConfig, out interface{}) error {
 	kfdef.Labels = config.Labels
 	kfdef.Annotations = config.Annotations
 	kfdef.Spec.Version = config.Spec.Version
+	kfdef.Spec.StrictParameters = config.Spec.StrictParameters
+	kfdef.Spec.Images = toKfDefImages(config.Spec.Images)
+	kfdef.Spec.Patches = toKfDefPatches(config.Spec.Patches)
+	kfdef.Spec.Proxy = toKfDefProxy(config.Spec.Proxy)
+	kfdef.Spec.ImagePullSecrets = toKfDefImagePullSecrets(config.Spec.ImagePullSecrets)
+	kfdef.Spec.ImagePullPolicy = config.Spec.ImagePullPolicy
+	kfdef.Spec.CommonLabels = config.Spec.CommonLabels
+	kfdef.Spec.CommonAnnotations = config.Spec.CommonAnnotations
 
 	for _, app := range config.Spec.Applications {
 		application := kfdeftypes.Application{
-			Name: app.Name,
+			Name:              app.Name,
+			Images:            toKfDefImages(app.Images),
+			Patches:           toKfDefPatches(app.Patches),
+			Placement:         toKfDefPlacement(app.Placement),
+			ImagePullSecrets:  toKfDefImagePullSecrets(app.ImagePullSecrets),
+			ImagePullPolicy:   app.ImagePullPolicy,
+			HardenPodSecurity: app.HardenPodSecurity,
+			CommonLabels:      app.CommonLabels,
+			CommonAnnotations: app.CommonAnnotations,
+			Resources:         app.Resources,
 		}
 		if app.KustomizeConfig != nil {
 			kconfig := &kfdeftypes.KustomizeConfig{
@@ -185,13 +279,56 @@ func (v V1) LoadKfDef(config kfconfig.KfConfig, out interface{}) error {
 			}
 			for _, param := range app.KustomizeConfig.Parameters {
 				p := kfdeftypes.NameValue{
-					Name:  param.Name,
-					Value: param.Value,
+					Name:      param.Name,
+					Value:     param.Value,
+					ValueFrom: toKfDefValueSource(param.ValueFrom),
 				}
 				kconfig.Parameters = append(kconfig.Parameters, p)
 			}
 			application.KustomizeConfig = kconfig
 		}
+		if app.HelmConfig != nil {
+			hconfig := &kfdeftypes.HelmConfig{
+				ChartRepo: app.HelmConfig.ChartRepo,
+				Chart:     app.HelmConfig.Chart,
+				Version:   app.HelmConfig.Version,
+				Values:    app.HelmConfig.Values,
+			}
+			if app.HelmConfig.RepoRef != nil {
+				hconfig.RepoRef = &kfdeftypes.RepoRef{
+					Name: app.HelmConfig.RepoRef.Name,
+					Path: app.HelmConfig.RepoRef.Path,
+				}
+			}
+			for _, ref := range app.HelmConfig.ValuesFrom {
+				hconfig.ValuesFrom = append(hconfig.ValuesFrom, kfdeftypes.ValuesReference{
+					Kind:     ref.Kind,
+					Name:     ref.Name,
+					Key:      ref.Key,
+					Optional: ref.Optional,
+				})
+			}
+			application.HelmConfig = hconfig
+		}
+		if app.ManifestsConfig != nil {
+			mconfig := &kfdeftypes.ManifestsConfig{
+				Template: app.ManifestsConfig.Template,
+			}
+			if app.ManifestsConfig.RepoRef != nil {
+				mconfig.RepoRef = &kfdeftypes.RepoRef{
+					Name: app.ManifestsConfig.RepoRef.Name,
+					Path: app.ManifestsConfig.RepoRef.Path,
+				}
+			}
+			for _, param := range app.ManifestsConfig.Parameters {
+				mconfig.Parameters = append(mconfig.Parameters, kfdeftypes.NameValue{
+					Name:      param.Name,
+					Value:     param.Value,
+					ValueFrom: toKfDefValueSource(param.ValueFrom),
+				})
+			}
+			application.ManifestsConfig = mconfig
+		}
 		kfdef.Spec.Applications = append(kfdef.Spec.Applications, application)
 	}
 
@@ -216,14 +353,30 @@ func (v V1) LoadKfDef(config kfconfig.KfConfig, out interface{}) error {
 					Name: secret.SecretSource.EnvSource.Name,
 				}
 			}
+			// References to objects hold no secret value, so they're kept.
+			if ref := secret.SecretSource.SecretKeyRef; ref != nil {
+				s.SecretSource.SecretKeyRef = &kfdeftypes.ObjectKeyRef{Namespace: ref.Namespace, Name: ref.Name, Key: ref.Key}
+			}
+			if ref := secret.SecretSource.ConfigMapKeyRef; ref != nil {
+				s.SecretSource.ConfigMapKeyRef = &kfdeftypes.ObjectKeyRef{Namespace: ref.Namespace, Name: ref.Name, Key: ref.Key}
+			}
 		}
 		kfdef.Spec.Secrets = append(kfdef.Spec.Secrets, s)
 	}
 
 	for _, repo := range config.Spec.Repos {
 		r := kfdeftypes.Repo{
-			Name: repo.Name,
-			URI:  repo.URI,
+			Name:         repo.Name,
+			URI:          repo.URI,
+			Ref:          repo.Ref,
+			Proxy:        repo.Proxy,
+			Timeout:      repo.Timeout,
+			PollInterval: repo.PollInterval,
+		}
+		if repo.SecretRef != nil {
+			r.SecretRef = &kfdeftypes.SecretRef{
+				Name: repo.SecretRef.Name,
+			}
 		}
 		kfdef.Spec.Repos = append(kfdef.Spec.Repos, r)
 	}
@@ -242,11 +395,13 @@ func (v V1) LoadKfDef(config kfconfig.KfConfig, out interface{}) error {
 
 	for _, cache := range config.Status.Caches {
 		c := kfdeftypes.RepoCache{
-			Name:      cache.Name,
-			LocalPath: cache.LocalPath,
+			Name:             cache.Name,
+			LocalPath:        cache.LocalPath,
+			ResolvedRevision: cache.ResolvedRevision,
 		}
 		kfdef.Status.ReposCache = append(kfdef.Status.ReposCache, c)
 	}
+	kfdef.Status.RenderedKinds = config.Status.RenderedKinds
 
 	kfdefBytes, err := yaml.Marshal(kfdef)
 	if err != nil {
@@ -266,3 +421,167 @@ func (v V1) LoadKfDef(config kfconfig.KfConfig, out interface{}) error {
 		}
 	}
 }
+
+func toKfConfigValueSource(src *kfdeftypes.ValueSource) *kfconfig.ValueSource {
+	if src == nil {
+		return nil
+	}
+	out := &kfconfig.ValueSource{}
+	if ref := src.SecretKeyRef; ref != nil {
+		out.SecretKeyRef = &kfconfig.ObjectKeyRef{Namespace: ref.Namespace, Name: ref.Name, Key: ref.Key}
+	}
+	if ref := src.ConfigMapKeyRef; ref != nil {
+		out.ConfigMapKeyRef = &kfconfig.ObjectKeyRef{Namespace: ref.Namespace, Name: ref.Name, Key: ref.Key}
+	}
+	return out
+}
+
+func toKfDefValueSource(src *kfconfig.ValueSource) *kfdeftypes.ValueSource {
+	if src == nil {
+		return nil
+	}
+	out := &kfdeftypes.ValueSource{}
+	if ref := src.SecretKeyRef; ref != nil {
+		out.SecretKeyRef = &kfdeftypes.ObjectKeyRef{Namespace: ref.Namespace, Name: ref.Name, Key: ref.Key}
+	}
+	if ref := src.ConfigMapKeyRef; ref != nil {
+		out.ConfigMapKeyRef = &kfdeftypes.ObjectKeyRef{Namespace: ref.Namespace, Name: ref.Name, Key: ref.Key}
+	}
+	return out
+}
+
+func toKfConfigImages(images []kfdeftypes.Image) []kfconfig.Image {
+	var out []kfconfig.Image
+	for _, image := range images {
+		out = append(out, kfconfig.Image{
+			Name:    image.Name,
+			NewName: image.NewName,
+			NewTag:  image.NewTag,
+			Digest:  image.Digest,
+		})
+	}
+	return out
+}
+
+func toKfDefImages(images []kfconfig.Image) []kfdeftypes.Image {
+	var out []kfdeftypes.Image
+	for _, image := range images {
+		out = append(out, kfdeftypes.Image{
+			Name:    image.Name,
+			NewName: image.NewName,
+			NewTag:  image.NewTag,
+			Digest:  image.Digest,
+		})
+	}
+	return out
+}
+
+func toKfConfigPatches(patches []kfdeftypes.Patch) []kfconfig.Patch {
+	var out []kfconfig.Patch
+	for _, patch := range patches {
+		p := kfconfig.Patch{Patch: patch.Patch}
+		if t := patch.Target; t != nil {
+			p.Target = &kfconfig.PatchTarget{
+				Group:         t.Group,
+				Version:       t.Version,
+				Kind:          t.Kind,
+				Name:          t.Name,
+				Namespace:     t.Namespace,
+				LabelSelector: t.LabelSelector,
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func toKfDefPatches(patches []kfconfig.Patch) []kfdeftypes.Patch {
+	var out []kfdeftypes.Patch
+	for _, patch := range patches {
+		p := kfdeftypes.Patch{Patch: patch.Patch}
+		if t := patch.Target; t != nil {
+			p.Target = &kfdeftypes.PatchTarget{
+				Group:         t.Group,
+				Version:       t.Version,
+				Kind:          t.Kind,
+				Name:          t.Name,
+				Namespace:     t.Namespace,
+				LabelSelector: t.LabelSelector,
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+func toKfConfigPlacement(placement *kfdeftypes.Placement) *kfconfig.Placement {
+	if placement == nil {
+		return nil
+	}
+	return &kfconfig.Placement{
+		NodeSelector:      placement.NodeSelector,
+		Tolerations:       placement.Tolerations,
+		Affinity:          placement.Affinity,
+		PriorityClassName: placement.PriorityClassName,
+	}
+}
+
+func toKfDefPlacement(placement *kfconfig.Placement) *kfdeftypes.Placement {
+	if placement == nil {
+		return nil
+	}
+	return &kfdeftypes.Placement{
+		NodeSelector:      placement.NodeSelector,
+		Tolerations:       placement.Tolerations,
+		Affinity:          placement.Affinity,
+		PriorityClassName: placement.PriorityClassName,
+	}
+}
+
+func toKfConfigProxy(proxy *kfdeftypes.Proxy) *kfconfig.Proxy {
+	if proxy == nil {
+		return nil
+	}
+	return &kfconfig.Proxy{
+		HTTPProxy:       proxy.HTTPProxy,
+		HTTPSProxy:      proxy.HTTPSProxy,
+		NoProxy:         proxy.NoProxy,
+		FromCluster:     proxy.FromCluster,
+		TrustedCABundle: proxy.TrustedCABundle,
+	}
+}
+
+func toKfDefProxy(proxy *kfconfig.Proxy) *kfdeftypes.Proxy {
+	if proxy == nil {
+		return nil
+	}
+	return &kfdeftypes.Proxy{
+		HTTPProxy:       proxy.HTTPProxy,
+		HTTPSProxy:      proxy.HTTPSProxy,
+		NoProxy:         proxy.NoProxy,
+		FromCluster:     proxy.FromCluster,
+		TrustedCABundle: proxy.TrustedCABundle,
+	}
+}
+
+func toKfConfigImagePullSecrets(secrets []kfdeftypes.ImagePullSecret) []kfconfig.ImagePullSecret {
+	var out []kfconfig.ImagePullSecret
+	for _, secret := range secrets {
+		out = append(out, kfconfig.ImagePullSecret{
+			Name:              secret.Name,
+			CopyFromNamespace: secret.CopyFromNamespace,
+		})
+	}
+	return out
+}
+
+func toKfDefImagePullSecrets(secrets []kfconfig.ImagePullSecret) []kfdeftypes.ImagePullSecret {
+	var out []kfdeftypes.ImagePullSecret
+	for _, secret := range secrets {
+		out = append(out, kfdeftypes.ImagePullSecret{
+			Name:              secret.Name,
+			CopyFromNamespace: secret.CopyFromNamespace,
+		})
+	}
+	return out
+}
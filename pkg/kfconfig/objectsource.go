@@ -0,0 +1,263 @@
+package kfconfig
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Kinds of the objects that can hold manifests.
+const (
+	ConfigMapKind = "ConfigMap"
+	SecretKind    = "Secret"
+)
+
+var objectSchemes = map[string]string{
+	"configmap://": ConfigMapKind,
+	"secret://":    SecretKind,
+}
+
+// IsObjectURI returns true if the repo URI points to a ConfigMap or Secret in the cluster.
+func IsObjectURI(uri string) bool {
+	for scheme := range objectSchemes {
+		if strings.HasPrefix(uri, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseObjectURI parses a configmap://namespace/name or secret://namespace/name URI into the kind,
+// namespace and name of the object. The namespace defaults to defaultNamespace if the URI only has a name.
+func ParseObjectURI(uri string, defaultNamespace string) (string, string, string, error) {
+	for scheme, kind := range objectSchemes {
+		if !strings.HasPrefix(uri, scheme) {
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(uri, scheme), "/")
+		switch {
+		case len(parts) == 1 && parts[0] != "":
+			return kind, defaultNamespace, parts[0], nil
+		case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+			return kind, parts[0], parts[1], nil
+		}
+	}
+	return "", "", "", &kfapis.KfError{
+		Code:    int(kfapis.INVALID_ARGUMENT),
+		Message: fmt.Sprintf("invalid URI %v; expected configmap://namespace/name or secret://namespace/name", uri),
+	}
+}
+
+// objectNamespace returns the namespace of an object the config reads, which defaults to the
+// namespace of the config. Objects of other namespaces can't be read: the operator can read
+// any of them, while the users creating a KfDef may not.
+func (c *KfConfig) objectNamespace(kind string, namespace string, name string) (string, error) {
+	if namespace == "" || namespace == c.Namespace {
+		return c.Namespace, nil
+	}
+	return "", &kfapis.KfError{
+		Code: int(kfapis.INVALID_ARGUMENT),
+		Message: fmt.Sprintf("%v %v/%v isn't in namespace %v; only objects of the namespace of the KfDef can be read",
+			strings.ToLower(kind), namespace, name, c.Namespace),
+	}
+}
+
+// readObjectSource returns the data of the ConfigMap or Secret the repo points to.
+func (c *KfConfig) readObjectSource(r Repo) (map[string][]byte, error) {
+	kind, namespace, name, err := ParseObjectURI(r.URI, c.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if namespace, err = c.objectNamespace(kind, namespace, name); err != nil {
+		return nil, err
+	}
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		return nil, &kfapis.KfError{
+			Code:    int(kfapis.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't create a client to read repo %v: %v", r.Name, err),
+		}
+	}
+
+	data := map[string][]byte{}
+	if kind == ConfigMapKind {
+		cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("couldn't read configmap %v/%v for repo %v: %v", namespace, name, r.Name, err),
+			}
+		}
+		for k, v := range cm.Data {
+			data[k] = []byte(v)
+		}
+		for k, v := range cm.BinaryData {
+			data[k] = v
+		}
+	} else {
+		secret, err := kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("couldn't read secret %v/%v for repo %v: %v", namespace, name, r.Name, err),
+			}
+		}
+		data = secret.Data
+	}
+	if len(data) == 0 {
+		return nil, &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("%v %v/%v for repo %v has no data", strings.ToLower(kind), namespace, name, r.Name),
+		}
+	}
+	return data, nil
+}
+
+// readObjectKey returns the value of a key of the ConfigMap or Secret, e.g. the source of a secret.
+// The object must be in the namespace of the config.
+func (c *KfConfig) readObjectKey(kind string, ref *ObjectKeyRef) (string, error) {
+	namespace, err := c.objectNamespace(kind, ref.Namespace, ref.Name)
+	if err != nil {
+		return "", err
+	}
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		return "", &kfapis.KfError{
+			Code:    int(kfapis.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't create a client to read %v %v/%v: %v", strings.ToLower(kind), namespace, ref.Name, err),
+		}
+	}
+
+	var value []byte
+	var found bool
+	if kind == ConfigMapKind {
+		cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("couldn't read configmap %v/%v: %v", namespace, ref.Name, err),
+			}
+		}
+		if v, ok := cm.Data[ref.Key]; ok {
+			value, found = []byte(v), true
+		} else {
+			value, found = cm.BinaryData[ref.Key]
+		}
+	} else {
+		secret, err := kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("couldn't read secret %v/%v: %v", namespace, ref.Name, err),
+			}
+		}
+		value, found = secret.Data[ref.Key]
+	}
+	if !found {
+		return "", &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("%v %v/%v has no key %v", strings.ToLower(kind), namespace, ref.Name, ref.Key),
+		}
+	}
+	return string(value), nil
+}
+
+// ResolveParameters returns a copy of the parameters where those setting ValueFrom have their
+// value read from the ConfigMap or Secret key it selects. The values are only resolved when an
+// application is rendered, so they aren't written to the config file.
+func (c *KfConfig) ResolveParameters(params []NameValue) ([]NameValue, error) {
+	resolved := make([]NameValue, 0, len(params))
+	for _, p := range params {
+		if p.ValueFrom != nil {
+			var err error
+			switch {
+			case p.ValueFrom.SecretKeyRef != nil:
+				p.Value, err = c.readObjectKey(SecretKind, p.ValueFrom.SecretKeyRef)
+			case p.ValueFrom.ConfigMapKeyRef != nil:
+				p.Value, err = c.readObjectKey(ConfigMapKind, p.ValueFrom.ConfigMapKeyRef)
+			default:
+				err = &kfapis.KfError{
+					Code:    int(kfapis.INVALID_ARGUMENT),
+					Message: fmt.Sprintf("parameter %v sets valueFrom without a secretKeyRef or configMapKeyRef", p.Name),
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+			p.ValueFrom = nil
+		}
+		resolved = append(resolved, p)
+	}
+	return resolved, nil
+}
+
+// objectDigest returns a digest of the data of a ConfigMap or Secret.
+func objectDigest(data map[string][]byte) string {
+	h := sha256.New()
+	for _, k := range sortedKeys(data) {
+		fmt.Fprintf(h, "%v\x00%v\x00", k, len(data[k]))
+		h.Write(data[k])
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
+}
+
+func sortedKeys(data map[string][]byte) []string {
+	keys := []string{}
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fetchObjectSource writes the manifests held in a ConfigMap or Secret into dir.
+// Keys ending in .tar.gz or .tgz are unpacked as gzipped tarballs; any other key is written
+// as a file named after the key. Since keys can't contain slashes, nested directories have
+// to be provided as a tarball.
+// It returns the path containing the manifests and a digest of the object's content.
+func (c *KfConfig) fetchObjectSource(r Repo, dir string) (string, string, error) {
+	data, err := c.readObjectSource(r)
+	if err != nil {
+		return "", "", err
+	}
+	for _, k := range sortedKeys(data) {
+		if strings.HasSuffix(k, ".tar.gz") || strings.HasSuffix(k, ".tgz") {
+			if err := untar(data[k], dir); err != nil {
+				return "", "", &kfapis.KfError{
+					Code:    int(kfapis.INVALID_ARGUMENT),
+					Message: fmt.Sprintf("couldn't unpack %v of %v: %v", k, r.URI, err),
+				}
+			}
+			continue
+		}
+		if err := ioutil.WriteFile(path.Join(dir, k), data[k], 0644); err != nil {
+			return "", "", err
+		}
+	}
+
+	localPath, err := singleSubdir(dir)
+	if err != nil {
+		return "", "", err
+	}
+	return localPath, objectDigest(data), nil
+}
+
+// singleSubdir returns the directory a tarball unpacked to if dir contains nothing else,
+// as is usually the case for GitHub style tarballs, and dir itself otherwise.
+func singleSubdir(dir string) (string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 1 && files[0].IsDir() {
+		return path.Join(dir, files[0].Name()), nil
+	}
+	return dir, nil
+}
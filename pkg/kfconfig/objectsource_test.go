@@ -0,0 +1,287 @@
+package kfconfig
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncCache_ObjectSource(t *testing.T) {
+	namespace := "opendatahub"
+	kubeClient := fake.NewSimpleClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: namespace},
+			Data: map[string]string{
+				"kustomization.yaml": "resources:\n- deployment.yaml\n",
+				"deployment.yaml":    "kind: Deployment\n",
+			},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "tarball", Namespace: namespace},
+			BinaryData: map[string][]byte{
+				"odh-manifests.tar.gz": newManifestsLayer(t, "odh-manifests", map[string]string{
+					"version": "v1",
+				}),
+			},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: "manifests-source"},
+			Data: map[string]string{
+				"kustomization.yaml": "resources:\n- deployment.yaml\n",
+			},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: namespace},
+			Data: map[string][]byte{
+				"version": []byte("v1"),
+			},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: namespace},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "traversal", Namespace: namespace},
+			BinaryData: map[string][]byte{
+				"odh-manifests.tar.gz": newTarball(t, tar.Header{Name: "../evil", Mode: 0644, Typeflag: tar.TypeReg}),
+			},
+		},
+	)
+	defer func(orig func() (kubernetes.Interface, error)) { newKubeClient = orig }(newKubeClient)
+	newKubeClient = func() (kubernetes.Interface, error) {
+		return kubeClient, nil
+	}
+
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	type testCase struct {
+		name          string
+		uri           string
+		expectedFile  string
+		expectedInDir string
+		expectedErr   string
+	}
+
+	testCases := []testCase{
+		{
+			name:         "configmap-files",
+			uri:          "configmap://manifests",
+			expectedFile: "kustomization.yaml",
+		},
+		{
+			name:          "configmap-tarball",
+			uri:           "configmap://tarball",
+			expectedFile:  "version",
+			expectedInDir: "odh-manifests",
+		},
+		{
+			name:        "other-namespace",
+			uri:         "configmap://manifests-source/manifests",
+			expectedErr: "isn't in namespace opendatahub",
+		},
+		{
+			name:         "secret-files",
+			uri:          "secret://opendatahub/manifests",
+			expectedFile: "version",
+		},
+		{
+			name:        "missing",
+			uri:         "configmap://opendatahub/missing",
+			expectedErr: "couldn't read configmap opendatahub/missing",
+		},
+		{
+			name:        "empty",
+			uri:         "configmap://opendatahub/empty",
+			expectedErr: "has no data",
+		},
+		{
+			name:        "traversal",
+			uri:         "configmap://opendatahub/traversal",
+			expectedErr: "outside of the extraction directory",
+		},
+		{
+			name:        "invalid",
+			uri:         "secret://a/b/c",
+			expectedErr: "invalid URI",
+		},
+	}
+
+	for _, c := range testCases {
+		config := &KfConfig{
+			Spec: KfConfigSpec{
+				AppDir: path.Join(testDir, c.name),
+				Repos: []Repo{{
+					Name: "manifests",
+					URI:  c.uri,
+				}},
+			},
+		}
+		config.Namespace = namespace
+
+		err := config.SyncCache()
+		if c.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expectedErr) {
+				t.Fatalf("%v: expected error containing %q; got %v", c.name, c.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: could not sync cache; %v", c.name, err)
+		}
+
+		localPath := path.Join(testDir, c.name, ".cache", "manifests", c.expectedInDir)
+		cache := config.Status.Caches[0]
+		if cache.LocalPath != localPath {
+			t.Fatalf("%v: LocalPath; got %v; want %v", c.name, cache.LocalPath, localPath)
+		}
+		if !strings.HasPrefix(cache.ResolvedRevision, "sha256:") {
+			t.Fatalf("%v: expected a digest as the resolved revision; got %v", c.name, cache.ResolvedRevision)
+		}
+		if _, err := os.Stat(path.Join(localPath, c.expectedFile)); err != nil {
+			t.Fatalf("%v: expected %v in the cache; %v", c.name, c.expectedFile, err)
+		}
+	}
+
+	if _, err := os.Stat(path.Join(testDir, "traversal", ".cache", "evil")); !os.IsNotExist(err) {
+		t.Errorf("expected no file outside of the cache of the repo; got %v", err)
+	}
+}
+
+func TestKfConfig_GetSecret_ObjectKeyRef(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "opendatahub"},
+			Data:       map[string][]byte{"password": []byte("secret-password")},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "opendatahub"},
+			Data:       map[string]string{"domain": "example.com"},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "shared"},
+			Data:       map[string]string{"domain": "example.com"},
+		},
+	)
+	defer func(orig func() (kubernetes.Interface, error)) { newKubeClient = orig }(newKubeClient)
+	newKubeClient = func() (kubernetes.Interface, error) {
+		return kubeClient, nil
+	}
+
+	newSecret := func(name string, source *SecretSource) Secret {
+		return Secret{Name: name, SecretSource: source}
+	}
+	d := &KfConfig{
+		Spec: KfConfigSpec{
+			Secrets: []Secret{
+				newSecret("secret", &SecretSource{
+					SecretKeyRef: &ObjectKeyRef{Name: "credentials", Key: "password"},
+				}),
+				newSecret("configmap", &SecretSource{
+					ConfigMapKeyRef: &ObjectKeyRef{Namespace: "opendatahub", Name: "settings", Key: "domain"},
+				}),
+				newSecret("missing-key", &SecretSource{
+					SecretKeyRef: &ObjectKeyRef{Name: "credentials", Key: "token"},
+				}),
+				newSecret("missing-object", &SecretSource{
+					SecretKeyRef: &ObjectKeyRef{Name: "settings", Key: "domain"},
+				}),
+				newSecret("other-namespace", &SecretSource{
+					ConfigMapKeyRef: &ObjectKeyRef{Namespace: "shared", Name: "settings", Key: "domain"},
+				}),
+			},
+		},
+	}
+	d.Namespace = "opendatahub"
+
+	type testCase struct {
+		name          string
+		expectedValue string
+		expectedErr   string
+	}
+
+	testCases := []testCase{
+		{name: "secret", expectedValue: "secret-password"},
+		{name: "configmap", expectedValue: "example.com"},
+		{name: "missing-key", expectedErr: "secret opendatahub/credentials has no key token"},
+		{name: "missing-object", expectedErr: "couldn't read secret opendatahub/settings"},
+		{name: "other-namespace", expectedErr: "configmap shared/settings isn't in namespace opendatahub"},
+	}
+
+	for _, c := range testCases {
+		actual, err := d.GetSecret(c.name)
+		if c.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expectedErr) {
+				t.Errorf("%v: expected error containing %q; got %v", c.name, c.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: could not get secret; %v", c.name, err)
+			continue
+		}
+		if actual != c.expectedValue {
+			t.Errorf("%v: got %v; want %v", c.name, actual, c.expectedValue)
+		}
+	}
+}
+
+func TestKfConfig_ResolveParameters(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "credentials", Namespace: "opendatahub"},
+			Data:       map[string][]byte{"password": []byte("secret-password")},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "opendatahub"},
+			Data:       map[string]string{"domain": "example.com"},
+		},
+	)
+	defer func(orig func() (kubernetes.Interface, error)) { newKubeClient = orig }(newKubeClient)
+	newKubeClient = func() (kubernetes.Interface, error) {
+		return kubeClient, nil
+	}
+
+	d := &KfConfig{}
+	d.Namespace = "opendatahub"
+
+	params := []NameValue{
+		{Name: "replicas", Value: "2"},
+		{Name: "password", ValueFrom: &ValueSource{
+			SecretKeyRef: &ObjectKeyRef{Name: "credentials", Key: "password"},
+		}},
+		{Name: "domain", ValueFrom: &ValueSource{
+			ConfigMapKeyRef: &ObjectKeyRef{Name: "settings", Key: "domain"},
+		}},
+	}
+	actual, err := d.ResolveParameters(params)
+	if err != nil {
+		t.Fatalf("could not resolve parameters; %v", err)
+	}
+	expected := []NameValue{
+		{Name: "replicas", Value: "2"},
+		{Name: "password", Value: "secret-password"},
+		{Name: "domain", Value: "example.com"},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("got %v; want %v", actual, expected)
+	}
+	if params[1].Value != "" || params[1].ValueFrom == nil {
+		t.Errorf("ResolveParameters modified its input; got %v", params[1])
+	}
+
+	_, err = d.ResolveParameters([]NameValue{{Name: "token", ValueFrom: &ValueSource{
+		SecretKeyRef: &ObjectKeyRef{Name: "credentials", Key: "token"},
+	}}})
+	if err == nil || !strings.Contains(err.Error(), "secret opendatahub/credentials has no key token") {
+		t.Errorf("expected a missing key error; got %v", err)
+	}
+}
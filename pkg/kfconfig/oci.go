@@ -0,0 +1,432 @@
+package kfconfig
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+
+	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ociScheme = "oci://"
+
+	ociManifestMediaType        = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType     = "application/vnd.docker.distribution.manifest.v2+json"
+	ociIndexMediaType           = "application/vnd.oci.image.index.v1+json"
+	dockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// ociManifestMediaTypes are the media types accepted for the manifest of a reference, including
+// image indexes of multi-arch images.
+var ociManifestMediaTypes = []string{ociManifestMediaType, dockerManifestMediaType, ociIndexMediaType, dockerManifestListMediaType}
+
+// ociManifest is the subset of an OCI (or docker v2 schema 2) image manifest needed to pull the layers.
+// For an image index, it lists the manifests of the index instead.
+type ociManifest struct {
+	MediaType string          `json:"mediaType,omitempty"`
+	Layers    []ociDescriptor `json:"layers,omitempty"`
+	Manifests []ociDescriptor `json:"manifests,omitempty"`
+}
+
+type ociDescriptor struct {
+	MediaType string       `json:"mediaType"`
+	Digest    string       `json:"digest"`
+	Size      int64        `json:"size"`
+	Platform  *ociPlatform `json:"platform,omitempty"`
+}
+
+type ociPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+// isIndex returns true if the manifest is an image index rather than an image manifest.
+func (m *ociManifest) isIndex() bool {
+	return m.MediaType == ociIndexMediaType || m.MediaType == dockerManifestListMediaType ||
+		(len(m.Manifests) > 0 && len(m.Layers) == 0)
+}
+
+// selectManifest returns the manifest of the index to pull: its only manifest, or else the one for
+// the platform the operator runs on.
+func (m *ociManifest) selectManifest() (*ociDescriptor, error) {
+	if len(m.Manifests) == 1 {
+		return &m.Manifests[0], nil
+	}
+	for i, desc := range m.Manifests {
+		if desc.Platform != nil && desc.Platform.OS == runtime.GOOS && desc.Platform.Architecture == runtime.GOARCH {
+			return &m.Manifests[i], nil
+		}
+	}
+	return nil, fmt.Errorf("image index has %v manifests and none for %v/%v; reference a manifest by digest",
+		len(m.Manifests), runtime.GOOS, runtime.GOARCH)
+}
+
+// ociReference is a parsed oci://registry/repository[:tag|@digest] URI.
+type ociReference struct {
+	Registry   string
+	Repository string
+	// Reference is either a tag or a digest.
+	Reference string
+}
+
+// IsOCIURI returns true if the repo URI points to an artifact in an OCI registry.
+func IsOCIURI(uri string) bool {
+	return strings.HasPrefix(uri, ociScheme)
+}
+
+func parseOCIReference(uri string) (*ociReference, error) {
+	ref := strings.TrimPrefix(uri, ociScheme)
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("invalid OCI reference %v; expected oci://registry/repository[:tag|@digest]", uri),
+		}
+	}
+	r := &ociReference{
+		Registry:   parts[0],
+		Repository: parts[1],
+		Reference:  "latest",
+	}
+	if i := strings.Index(r.Repository, "@"); i >= 0 {
+		r.Reference = r.Repository[i+1:]
+		r.Repository = r.Repository[:i]
+		if !strings.HasPrefix(r.Reference, "sha256:") {
+			return nil, &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("invalid OCI reference %v; only sha256 digests are supported", uri),
+			}
+		}
+	} else if i := strings.LastIndex(r.Repository, ":"); i > strings.LastIndex(r.Repository, "/") {
+		r.Reference = r.Repository[i+1:]
+		r.Repository = r.Repository[:i]
+	}
+	return r, nil
+}
+
+// baseURL returns the URL of the registry API. Plain http is only used for registries on the loopback interface.
+func (r *ociReference) baseURL() string {
+	host := r.Registry
+	if h, _, err := net.SplitHostPort(r.Registry); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return "http://" + r.Registry
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return "http://" + r.Registry
+	}
+	return "https://" + r.Registry
+}
+
+// ociClient is a minimal client for pulling from a registry implementing the OCI distribution spec.
+type ociClient struct {
+	client *http.Client
+	ref    *ociReference
+	auth   *repoAuth
+	// authorization is the Authorization header sent with each request.
+	authorization string
+	challenged    bool
+}
+
+// get issues a GET against the registry API, authenticating as directed by the registry
+// if it responds with an authentication challenge.
+func (c *ociClient) get(apiPath string, accept ...string) (*http.Response, error) {
+	return c.request("GET", apiPath, accept...)
+}
+
+func (c *ociClient) request(method string, apiPath string, accept ...string) (*http.Response, error) {
+	resp, err := c.do(method, apiPath, accept...)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && !c.challenged {
+		c.challenged = true
+		challenge := resp.Header.Get("Www-Authenticate")
+		resp.Body.Close()
+		if err := c.authenticate(challenge); err != nil {
+			return nil, err
+		}
+		return c.do(method, apiPath, accept...)
+	}
+	return resp, nil
+}
+
+func (c *ociClient) do(method string, apiPath string, accept ...string) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.ref.baseURL()+"/v2/"+c.ref.Repository+apiPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "kfctl")
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+	return c.client.Do(req)
+}
+
+// authenticate responds to an authentication challenge. Basic challenges are answered with the username
+// and password of the repo. For Bearer challenges a pull token is requested from the realm,
+// using the username and password if the repo has them and anonymously otherwise.
+func (c *ociClient) authenticate(challenge string) error {
+	if strings.HasPrefix(strings.ToLower(challenge), "basic") && c.auth != nil && c.auth.username != "" {
+		c.authorization = c.auth.authorization()
+		return nil
+	}
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("registry %v requires unsupported authentication %q", c.ref.Registry, challenge),
+		}
+	}
+	params := map[string]string{}
+	for _, p := range strings.Split(challenge[len("bearer "):], ",") {
+		kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+		if len(kv) == 2 {
+			params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("registry %v returned an invalid authentication challenge %q", c.ref.Registry, challenge),
+		}
+	}
+	q := realm.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + c.ref.Repository + ":pull"
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequest("GET", realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "kfctl")
+	if c.auth != nil && c.auth.username != "" {
+		req.SetBasicAuth(c.auth.username, c.auth.password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't get a token for %v from %v: %v", c.ref.Repository, params["realm"], resp.Status),
+		}
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	c.authorization = "Bearer " + token.Token
+	return nil
+}
+
+// readVerified reads the response body and checks it matches the expected digest, if any.
+// It returns the body and its digest.
+func readVerified(resp *http.Response, expected string) ([]byte, string, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	if expected != "" && digest != expected {
+		return nil, "", &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("digest mismatch; got %v; want %v", digest, expected),
+		}
+	}
+	return body, digest, nil
+}
+
+// resolveOCIDigest returns the digest of the image manifest an oci:// URI currently points to.
+func resolveOCIDigest(client *http.Client, auth *repoAuth, uri string) (string, error) {
+	ref, err := parseOCIReference(uri)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(ref.Reference, "sha256:") {
+		return ref.Reference, nil
+	}
+	c := newOCIClient(client, auth, ref)
+
+	// Registries return the digest on a HEAD request; fall back to hashing the manifest if they don't.
+	resp, err := c.request("HEAD", "/manifests/"+ref.Reference, ociManifestMediaTypes...)
+	if err == nil {
+		resp.Body.Close()
+		if digest := resp.Header.Get("Docker-Content-Digest"); resp.StatusCode == http.StatusOK && digest != "" {
+			return digest, nil
+		}
+	}
+	resp, err = c.get("/manifests/"+ref.Reference, ociManifestMediaTypes...)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't fetch manifest for %v: %v", uri, resp.Status),
+		}
+	}
+	_, digest, err := readVerified(resp, "")
+	return digest, err
+}
+
+func newOCIClient(client *http.Client, auth *repoAuth, ref *ociReference) *ociClient {
+	c := &ociClient{
+		client: client,
+		ref:    ref,
+		auth:   auth,
+	}
+	if auth != nil && auth.token != "" {
+		// A token is used as is, without going through the registry's token service.
+		c.authorization = auth.authorization()
+	}
+	return c
+}
+
+// fetchOCIArtifact pulls the manifest referenced by an oci:// URI and unpacks its gzipped tar layers into dir.
+// It returns the path containing the manifests and the digest of the image manifest.
+func fetchOCIArtifact(client *http.Client, auth *repoAuth, uri string, dir string) (string, string, error) {
+	ref, err := parseOCIReference(uri)
+	if err != nil {
+		return "", "", err
+	}
+	c := newOCIClient(client, auth, ref)
+
+	expectedDigest := ""
+	if strings.HasPrefix(ref.Reference, "sha256:") {
+		expectedDigest = ref.Reference
+	}
+	manifest, digest, err := c.fetchManifest(ref.Reference, expectedDigest)
+	if err != nil {
+		return "", "", &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't fetch manifest for %v: %v", uri, err),
+		}
+	}
+	if manifest.isIndex() {
+		desc, err := manifest.selectManifest()
+		if err != nil {
+			return "", "", &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("couldn't pull %v: %v", uri, err),
+			}
+		}
+		// The digest of the index is kept, since it is what the reference resolves to.
+		if manifest, _, err = c.fetchManifest(desc.Digest, desc.Digest); err != nil {
+			return "", "", &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("couldn't fetch manifest %v of %v: %v", desc.Digest, uri, err),
+			}
+		}
+		if manifest.isIndex() {
+			return "", "", &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("couldn't pull %v: nested image indexes aren't supported", uri),
+			}
+		}
+	}
+
+	unpacked := 0
+	for _, layer := range manifest.Layers {
+		if !isTarGzipMediaType(layer.MediaType) {
+			log.Infof("Skipping layer %v of %v with media type %v", layer.Digest, uri, layer.MediaType)
+			continue
+		}
+		if err := c.fetchLayer(layer, dir); err != nil {
+			return "", "", &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("couldn't fetch layer %v of %v: %v", layer.Digest, uri, err),
+			}
+		}
+		unpacked++
+	}
+	if unpacked == 0 {
+		return "", "", &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("%v has no gzipped tar layer containing manifests", uri),
+		}
+	}
+
+	localPath, err := singleSubdir(dir)
+	if err != nil {
+		return "", "", err
+	}
+	return localPath, digest, nil
+}
+
+// fetchManifest returns the manifest or image index of the reference, a tag or a digest, and its digest.
+// It is checked against the expected digest, if any.
+func (c *ociClient) fetchManifest(reference string, expectedDigest string) (*ociManifest, string, error) {
+	resp, err := c.get("/manifests/"+reference, ociManifestMediaTypes...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	body, digest, err := readVerified(resp, expectedDigest)
+	if err != nil {
+		return nil, "", err
+	}
+	manifest := &ociManifest{}
+	if err := json.Unmarshal(body, manifest); err != nil {
+		return nil, "", fmt.Errorf("couldn't parse manifest: %v", err)
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType = strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+	}
+	return manifest, digest, nil
+}
+
+func (c *ociClient) fetchLayer(layer ociDescriptor, dir string) error {
+	resp, err := c.get("/blobs/" + layer.Digest)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	body, _, err := readVerified(resp, layer.Digest)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	return untar(body, dir)
+}
+
+func isTarGzipMediaType(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "tar+gzip") || strings.HasSuffix(mediaType, "tar.gzip")
+}
@@ -0,0 +1,350 @@
+package kfconfig
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeRegistry serves a single repository with one tagged manifest, requiring a bearer token like
+// public registries do for anonymous pulls.
+type fakeRegistry struct {
+	repository string
+	tag        string
+	manifest   []byte
+	blobs      map[string][]byte
+	// manifests are other manifests or image indexes, by tag or digest.
+	manifests map[string][]byte
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		if r.URL.Query().Get("scope") != "repository:"+f.repository+":pull" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"token": "anonymous"}`))
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer anonymous" {
+		w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm="http://%v/token",service="fake"`, r.Host))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	prefix := "/v2/" + f.repository
+	switch {
+	case r.URL.Path == prefix+"/manifests/"+f.tag || r.URL.Path == prefix+"/manifests/"+ociDigest(f.manifest):
+		w.Header().Set("Content-Type", ociManifestMediaType)
+		w.Write(f.manifest)
+	case strings.HasPrefix(r.URL.Path, prefix+"/manifests/") && f.manifests[strings.TrimPrefix(r.URL.Path, prefix+"/manifests/")] != nil:
+		manifest := f.manifests[strings.TrimPrefix(r.URL.Path, prefix+"/manifests/")]
+		m := &ociManifest{}
+		json.Unmarshal(manifest, m)
+		w.Header().Set("Content-Type", m.MediaType)
+		w.Write(manifest)
+	case strings.HasPrefix(r.URL.Path, prefix+"/blobs/"):
+		blob, ok := f.blobs[strings.TrimPrefix(r.URL.Path, prefix+"/blobs/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(blob)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func ociDigest(b []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(b))
+}
+
+func newManifestsLayer(t *testing.T, dir string, files map[string]string) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{
+		Name:     dir + "/",
+		Mode:     0755,
+		Typeflag: tar.TypeDir,
+	}); err != nil {
+		t.Fatalf("failed to write tar header; %v", err)
+	}
+	for name, content := range files {
+		name = dir + "/" + name
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			t.Fatalf("failed to write tar header; %v", err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestSyncCache_OCI(t *testing.T) {
+	layer := newManifestsLayer(t, "odh-manifests", map[string]string{
+		"version": "v1",
+	})
+	manifest, _ := json.Marshal(ociManifest{
+		MediaType: ociManifestMediaType,
+		Layers: []ociDescriptor{
+			{
+				MediaType: "application/vnd.oci.image.config.v1+json",
+				Digest:    ociDigest([]byte("{}")),
+				Size:      2,
+			},
+			{
+				MediaType: "application/vnd.oci.image.layer.v1.tar+gzip",
+				Digest:    ociDigest(layer),
+				Size:      int64(len(layer)),
+			},
+		},
+	})
+	registry := &fakeRegistry{
+		repository: "opendatahub/odh-manifests",
+		tag:        "v1.0",
+		manifest:   manifest,
+		blobs: map[string][]byte{
+			ociDigest(layer): layer,
+		},
+	}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	type testCase struct {
+		name        string
+		uri         string
+		expectedErr bool
+	}
+
+	testCases := []testCase{
+		{
+			name: "tag",
+			uri:  "oci://" + host + "/opendatahub/odh-manifests:v1.0",
+		},
+		{
+			name: "digest",
+			uri:  "oci://" + host + "/opendatahub/odh-manifests@" + ociDigest(manifest),
+		},
+		{
+			name:        "missing",
+			uri:         "oci://" + host + "/opendatahub/odh-manifests:v2.0",
+			expectedErr: true,
+		},
+		{
+			name:        "digest mismatch",
+			uri:         "oci://" + host + "/opendatahub/odh-manifests@" + ociDigest([]byte("other")),
+			expectedErr: true,
+		},
+	}
+
+	for _, c := range testCases {
+		repoName := "manifests"
+		config := &KfConfig{
+			Spec: KfConfigSpec{
+				AppDir: path.Join(testDir, c.name),
+				Repos: []Repo{{
+					Name: repoName,
+					URI:  c.uri,
+				}},
+			},
+		}
+
+		err := config.SyncCache()
+		if c.expectedErr {
+			if err == nil {
+				t.Fatalf("%v: expected an error syncing %v", c.name, c.uri)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: could not sync cache; %v", c.name, err)
+		}
+
+		expected := []Cache{{
+			Name:             repoName,
+			LocalPath:        path.Join(testDir, c.name, ".cache", repoName, "odh-manifests"),
+			ResolvedRevision: ociDigest(manifest),
+		}}
+		if !reflect.DeepEqual(config.Status.Caches, expected) {
+			t.Fatalf("%v: caches; got %+v; want %+v", c.name, config.Status.Caches, expected)
+		}
+		version, err := ioutil.ReadFile(path.Join(expected[0].LocalPath, "version"))
+		if err != nil || string(version) != "v1" {
+			t.Fatalf("%v: unpacked version; got %v, %v; want v1", c.name, string(version), err)
+		}
+	}
+}
+
+func TestSyncCache_OCIIndex(t *testing.T) {
+	layer := newManifestsLayer(t, "odh-manifests", map[string]string{
+		"version": "v1",
+	})
+	manifest, _ := json.Marshal(ociManifest{
+		MediaType: ociManifestMediaType,
+		Layers: []ociDescriptor{{
+			MediaType: "application/vnd.oci.image.layer.v1.tar+gzip",
+			Digest:    ociDigest(layer),
+			Size:      int64(len(layer)),
+		}},
+	})
+	other, _ := json.Marshal(ociManifest{MediaType: ociManifestMediaType})
+	newIndex := func(platforms ...ociPlatform) []byte {
+		index := ociManifest{MediaType: ociIndexMediaType}
+		for i := range platforms {
+			m := other
+			if platforms[i].OS == runtime.GOOS && platforms[i].Architecture == runtime.GOARCH {
+				m = manifest
+			}
+			index.Manifests = append(index.Manifests, ociDescriptor{
+				MediaType: ociManifestMediaType,
+				Digest:    ociDigest(m),
+				Size:      int64(len(m)),
+				Platform:  &platforms[i],
+			})
+		}
+		b, _ := json.Marshal(index)
+		return b
+	}
+	multiArch := newIndex(ociPlatform{OS: "linux", Architecture: "s390x"}, ociPlatform{OS: runtime.GOOS, Architecture: runtime.GOARCH})
+	single := newIndex(ociPlatform{OS: runtime.GOOS, Architecture: runtime.GOARCH})
+	otherArch := newIndex(ociPlatform{OS: "linux", Architecture: "s390x"}, ociPlatform{OS: "linux", Architecture: "ppc64le"})
+	registry := &fakeRegistry{
+		repository: "opendatahub/odh-manifests",
+		manifests: map[string][]byte{
+			"multi-arch":        multiArch,
+			"single":            single,
+			"other-arch":        otherArch,
+			ociDigest(manifest): manifest,
+			ociDigest(other):    other,
+		},
+		blobs: map[string][]byte{
+			ociDigest(layer): layer,
+		},
+	}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	type testCase struct {
+		name        string
+		tag         string
+		expectedErr bool
+	}
+
+	testCases := []testCase{
+		{name: "multi-arch", tag: "multi-arch"},
+		{name: "single", tag: "single"},
+		{name: "other-arch", tag: "other-arch", expectedErr: true},
+	}
+
+	for _, c := range testCases {
+		config := &KfConfig{
+			Spec: KfConfigSpec{
+				AppDir: path.Join(testDir, c.name),
+				Repos: []Repo{{
+					Name: "manifests",
+					URI:  "oci://" + host + "/opendatahub/odh-manifests:" + c.tag,
+				}},
+			},
+		}
+
+		err := config.SyncCache()
+		if c.expectedErr {
+			if err == nil || !strings.Contains(err.Error(), "reference a manifest by digest") {
+				t.Fatalf("%v: expected an error about the platforms; got %v", c.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: could not sync cache; %v", c.name, err)
+		}
+		// The revision is the digest of the index the tag points to.
+		if revision := config.Status.Caches[0].ResolvedRevision; revision != ociDigest(registry.manifests[c.tag]) {
+			t.Errorf("%v: resolved revision; got %v; want the digest of the index", c.name, revision)
+		}
+		version, err := ioutil.ReadFile(path.Join(config.Status.Caches[0].LocalPath, "version"))
+		if err != nil || string(version) != "v1" {
+			t.Fatalf("%v: unpacked version; got %v, %v; want v1", c.name, string(version), err)
+		}
+	}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	type testCase struct {
+		uri      string
+		expected *ociReference
+		baseURL  string
+	}
+
+	testCases := []testCase{
+		{
+			uri: "oci://quay.io/opendatahub/odh-manifests:v1.4",
+			expected: &ociReference{
+				Registry:   "quay.io",
+				Repository: "opendatahub/odh-manifests",
+				Reference:  "v1.4",
+			},
+			baseURL: "https://quay.io",
+		},
+		{
+			uri: "oci://localhost:5000/odh-manifests",
+			expected: &ociReference{
+				Registry:   "localhost:5000",
+				Repository: "odh-manifests",
+				Reference:  "latest",
+			},
+			baseURL: "http://localhost:5000",
+		},
+		{
+			uri: "oci://registry.example.com:8443/odh/odh-manifests@sha256:abc",
+			expected: &ociReference{
+				Registry:   "registry.example.com:8443",
+				Repository: "odh/odh-manifests",
+				Reference:  "sha256:abc",
+			},
+			baseURL: "https://registry.example.com:8443",
+		},
+	}
+
+	for _, c := range testCases {
+		actual, err := parseOCIReference(c.uri)
+		if err != nil {
+			t.Fatalf("could not parse %v; %v", c.uri, err)
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("parseOCIReference(%v); got %+v; want %+v", c.uri, actual, c.expected)
+		}
+		if actual.baseURL() != c.baseURL {
+			t.Errorf("baseURL for %v; got %v; want %v", c.uri, actual.baseURL(), c.baseURL)
+		}
+	}
+
+	if _, err := parseOCIReference("oci://odh-manifests"); err == nil {
+		t.Errorf("expected an error for a reference without a registry")
+	}
+}
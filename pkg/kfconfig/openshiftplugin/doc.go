@@ -0,0 +1,22 @@
+// Copyright 2018 The Kubeflow Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openshiftplugin contains the spec of the KfOpenShiftPlugin
+// +k8s:openapi-gen=true
+// +k8s:deepcopy-gen=package,register
+// +k8s:conversion-gen=github.com/opendatahub-io/opendatahub-operator/pkg/kfconfig/openshiftplugin
+// +k8s:defaulter-gen=TypeMeta
+// +groupName=openshiftplugin.internal.kubeflow.org
+
+package openshiftplugin
@@ -0,0 +1,151 @@
+package openshiftplugin
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultClusterProxyConfigMapName is the ConfigMap the cluster proxy settings are written to by default.
+	DefaultClusterProxyConfigMapName = "cluster-proxy"
+	// DefaultTrustedCABundleConfigMapName is the ConfigMap the trusted CA bundle is injected into by default.
+	DefaultTrustedCABundleConfigMapName = "trusted-cabundle"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// +k8s:openapi-gen=true
+// Placeholder for the plugin API.
+type KfOpenShiftPlugin struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec OpenShiftPluginSpec `json:"spec,omitempty"`
+}
+
+// OpenShiftPluginSpec defines the OpenShift specific setup of the KfDef namespace.
+type OpenShiftPluginSpec struct {
+	// SecurityContextConstraints lists the SCCs service accounts of the KfDef namespace are allowed to use.
+	SecurityContextConstraints []SCCBinding `json:"securityContextConstraints,omitempty"`
+
+	// Routes lists the routes exposing services of the KfDef namespace.
+	Routes []Route `json:"routes,omitempty"`
+
+	// ClusterProxy propagates the cluster wide proxy settings and trusted CA bundle to the KfDef namespace.
+	ClusterProxy *ClusterProxy `json:"clusterProxy,omitempty"`
+
+	// OAuthProxies lists the services put behind the OpenShift OAuth proxy.
+	OAuthProxies []OAuthProxy `json:"oauthProxies,omitempty"`
+}
+
+// AllowedSCCs are the security context constraints service accounts can be allowed to use.
+// Privileged SCCs, e.g. privileged or hostaccess, would let anyone able to create a KfDef
+// escalate their privileges, so they have to be granted by a cluster admin.
+var AllowedSCCs = []string{"restricted", "anyuid", "nonroot"}
+
+// SCCBinding allows service accounts to use a security context constraint.
+type SCCBinding struct {
+	// Name of the security context constraint, one of restricted, anyuid or nonroot.
+	Name string `json:"name,omitempty"`
+
+	ServiceAccounts []string `json:"serviceAccounts,omitempty"`
+}
+
+// Route exposes a service.
+type Route struct {
+	Name string `json:"name,omitempty"`
+
+	// Service is the name of the service the route points to. Defaults to the name of the route.
+	Service string `json:"service,omitempty"`
+
+	// TargetPort is the name or number of the service port the route points to.
+	TargetPort string `json:"targetPort,omitempty"`
+
+	// Host is generated by the router if empty.
+	Host string `json:"host,omitempty"`
+
+	// TLSTermination is edge, passthrough or reencrypt. The route isn't secured if empty.
+	TLSTermination string `json:"tlsTermination,omitempty"`
+}
+
+// ClusterProxy names the ConfigMaps the cluster proxy settings are propagated to.
+type ClusterProxy struct {
+	// ConfigMapName is the ConfigMap the HTTP_PROXY, HTTPS_PROXY and NO_PROXY settings of the
+	// cluster proxy are written to. Defaults to cluster-proxy.
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// TrustedCABundleConfigMapName is the ConfigMap the cluster network operator injects the
+	// trusted CA bundle into. Defaults to trusted-cabundle.
+	TrustedCABundleConfigMapName string `json:"trustedCABundleConfigMapName,omitempty"`
+}
+
+// OAuthProxy wires an OpenShift OAuth proxy sidecar running as a service account and served by a service.
+type OAuthProxy struct {
+	// ServiceAccount the OAuth proxy runs as. It's used as the OAuth client and is created if it
+	// doesn't exist, in which case it's deleted with the KfDef.
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+
+	// Route the OAuth server redirects to once logged in.
+	Route string `json:"route,omitempty"`
+
+	// Service serving the OAuth proxy. It's given a serving certificate in the <service>-tls secret.
+	Service string `json:"service,omitempty"`
+}
+
+// IsValid returns true if the spec is a valid and complete spec.
+// If false it will also return a string providing a message about why its invalid.
+func (plugin *OpenShiftPluginSpec) IsValid() (bool, string) {
+	for _, scc := range plugin.SecurityContextConstraints {
+		if scc.Name == "" {
+			return false, "SecurityContextConstraints requires name. "
+		}
+		if !sccAllowed(scc.Name) {
+			return false, fmt.Sprintf("SecurityContextConstraint %v isn't allowed; allowed SCCs are %v. ", scc.Name, AllowedSCCs)
+		}
+	}
+
+	for _, r := range plugin.Routes {
+		if r.Name == "" {
+			return false, "Routes requires name. "
+		}
+		switch r.TLSTermination {
+		case "", "edge", "passthrough", "reencrypt":
+		default:
+			return false, fmt.Sprintf("Route %v has invalid tlsTermination %v. ", r.Name, r.TLSTermination)
+		}
+	}
+
+	for _, p := range plugin.OAuthProxies {
+		if p.ServiceAccount == "" || p.Route == "" || p.Service == "" {
+			return false, "OAuthProxies requires serviceAccount, route and service. "
+		}
+	}
+
+	return true, ""
+}
+
+func sccAllowed(name string) bool {
+	for _, allowed := range AllowedSCCs {
+		if name == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// GetConfigMapName returns the name of the ConfigMap the cluster proxy settings are written to.
+func (p *ClusterProxy) GetConfigMapName() string {
+	if p.ConfigMapName == "" {
+		return DefaultClusterProxyConfigMapName
+	}
+	return p.ConfigMapName
+}
+
+// GetTrustedCABundleConfigMapName returns the name of the ConfigMap the trusted CA bundle is injected into.
+func (p *ClusterProxy) GetTrustedCABundleConfigMapName() string {
+	if p.TrustedCABundleConfigMapName == "" {
+		return DefaultTrustedCABundleConfigMapName
+	}
+	return p.TrustedCABundleConfigMapName
+}
@@ -0,0 +1,122 @@
+package kfconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
+	kftypesv3 "github.com/opendatahub-io/opendatahub-operator/apis/apps"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Keys read from the Secret referenced by Repo.SecretRef.
+const (
+	RepoSecretUsernameKey = "username"
+	RepoSecretPasswordKey = "password"
+	RepoSecretTokenKey    = "token"
+	RepoSecretCAKey       = "ca.crt"
+)
+
+// newKubeClient returns the client used to read repo credentials from the cluster.
+// Tests replace it with a fake clientset.
+var newKubeClient = func() (kubernetes.Interface, error) {
+	config := kftypesv3.GetConfig()
+	if config == nil {
+		return nil, fmt.Errorf("no kubeconfig or in-cluster config available")
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// repoAuth holds the credentials read from the Secret referenced by a repo.
+type repoAuth struct {
+	username string
+	password string
+	token    string
+	caBundle []byte
+}
+
+// authorization returns the value of the Authorization header for the credentials, if any.
+func (a *repoAuth) authorization() string {
+	if a == nil {
+		return ""
+	}
+	if a.token != "" {
+		return "Bearer " + a.token
+	}
+	if a.username != "" || a.password != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(a.username+":"+a.password))
+	}
+	return ""
+}
+
+// getRepoAuth reads the credentials for the repo from the Secret it references.
+// It returns nil if the repo doesn't reference a Secret.
+func (c *KfConfig) getRepoAuth(r Repo) (*repoAuth, error) {
+	if r.SecretRef == nil || r.SecretRef.Name == "" {
+		return nil, nil
+	}
+	kubeClient, err := newKubeClient()
+	if err != nil {
+		return nil, &kfapis.KfError{
+			Code:    int(kfapis.INTERNAL_ERROR),
+			Message: fmt.Sprintf("couldn't create a client to read the credentials of repo %v: %v", r.Name, err),
+		}
+	}
+	secret, err := kubeClient.CoreV1().Secrets(c.Namespace).Get(context.TODO(), r.SecretRef.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't read secret %v/%v for repo %v: %v", c.Namespace, r.SecretRef.Name, r.Name, err),
+		}
+	}
+	return &repoAuth{
+		username: string(secret.Data[RepoSecretUsernameKey]),
+		password: string(secret.Data[RepoSecretPasswordKey]),
+		token:    string(secret.Data[RepoSecretTokenKey]),
+		caBundle: secret.Data[RepoSecretCAKey],
+	}, nil
+}
+
+// newRepoHTTPClient returns the client used to download the repo, honoring its proxy, timeout and CA bundle.
+func newRepoHTTPClient(r Repo, auth *repoAuth) (*http.Client, error) {
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	}
+	if r.Proxy != "" {
+		proxyURL, err := url.Parse(r.Proxy)
+		if err != nil {
+			return nil, &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("invalid proxy %v for repo %v: %v", r.Proxy, r.Name, err),
+			}
+		}
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+	if auth != nil && len(auth.caBundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(auth.caBundle) {
+			return nil, &kfapis.KfError{
+				Code:    int(kfapis.INVALID_ARGUMENT),
+				Message: fmt.Sprintf("%v of the secret for repo %v contains no PEM encoded certificates", RepoSecretCAKey, r.Name),
+			}
+		}
+		t.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	t.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	t.RegisterProtocol("", http.NewFileTransport(http.Dir("/")))
+
+	hclient := &http.Client{Transport: t}
+	if r.Timeout != nil {
+		hclient.Timeout = r.Timeout.Duration
+	}
+	return hclient, nil
+}
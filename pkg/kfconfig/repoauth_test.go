@@ -0,0 +1,186 @@
+package kfconfig
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncCache_RepoAuth(t *testing.T) {
+	tarball := newManifestsLayer(t, "odh-manifests", map[string]string{
+		"version": "v1",
+	})
+	serveTarball := func(authorization string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != authorization {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write(tarball)
+		}
+	}
+
+	tlsServer := httptest.NewTLSServer(serveTarball("Bearer s3cr3t"))
+	defer tlsServer.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+
+	basicServer := httptest.NewServer(serveTarball("Basic b2RoOnBhc3N3b3Jk"))
+	defer basicServer.Close()
+
+	// The proxy answers requests for any host with the tarball.
+	proxied := ""
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write(tarball)
+	}))
+	defer proxy.Close()
+
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.Write(tarball)
+	}))
+	defer slowServer.Close()
+
+	namespace := "opendatahub"
+	kubeClient := fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: namespace},
+			Data: map[string][]byte{
+				RepoSecretTokenKey: []byte("s3cr3t"),
+				RepoSecretCAKey:    caBundle,
+			},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "basic", Namespace: namespace},
+			Data: map[string][]byte{
+				RepoSecretUsernameKey: []byte("odh"),
+				RepoSecretPasswordKey: []byte("password"),
+			},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "bad-ca", Namespace: namespace},
+			Data: map[string][]byte{
+				RepoSecretCAKey: []byte("not a certificate"),
+			},
+		},
+	)
+	defer func(orig func() (kubernetes.Interface, error)) { newKubeClient = orig }(newKubeClient)
+	newKubeClient = func() (kubernetes.Interface, error) {
+		return kubeClient, nil
+	}
+
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	type testCase struct {
+		name        string
+		repo        Repo
+		expectedErr string
+	}
+
+	testCases := []testCase{
+		{
+			name: "token-and-ca",
+			repo: Repo{
+				URI:       tlsServer.URL + "/manifests.tar.gz",
+				SecretRef: &SecretRef{Name: "token"},
+			},
+		},
+		{
+			name:        "no-ca",
+			repo:        Repo{URI: tlsServer.URL + "/manifests.tar.gz"},
+			expectedErr: "certificate",
+		},
+		{
+			name: "basic",
+			repo: Repo{
+				URI:       basicServer.URL + "/manifests.tar.gz",
+				SecretRef: &SecretRef{Name: "basic"},
+			},
+		},
+		{
+			name:        "unauthorized",
+			repo:        Repo{URI: basicServer.URL + "/manifests.tar.gz"},
+			expectedErr: "401",
+		},
+		{
+			name:        "missing-secret",
+			repo:        Repo{URI: basicServer.URL + "/manifests.tar.gz", SecretRef: &SecretRef{Name: "missing"}},
+			expectedErr: "couldn't read secret opendatahub/missing",
+		},
+		{
+			name:        "bad-ca",
+			repo:        Repo{URI: basicServer.URL + "/manifests.tar.gz", SecretRef: &SecretRef{Name: "bad-ca"}},
+			expectedErr: "no PEM encoded certificates",
+		},
+		{
+			name: "proxy",
+			repo: Repo{
+				URI:   "http://manifests.example.com/manifests.tar.gz",
+				Proxy: proxy.URL,
+			},
+		},
+		{
+			name: "timeout",
+			repo: Repo{
+				URI:     slowServer.URL + "/manifests.tar.gz",
+				Timeout: &metav1.Duration{Duration: 50 * time.Millisecond},
+			},
+			expectedErr: "Client.Timeout",
+		},
+	}
+
+	for _, c := range testCases {
+		c.repo.Name = "manifests"
+		config := &KfConfig{
+			Spec: KfConfigSpec{
+				AppDir: path.Join(testDir, c.name),
+				Repos:  []Repo{c.repo},
+			},
+		}
+		config.Namespace = namespace
+
+		err := config.SyncCache()
+		if c.expectedErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.expectedErr) {
+				t.Fatalf("%v: expected error containing %q; got %v", c.name, c.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: could not sync cache; %v", c.name, err)
+		}
+		localPath := path.Join(testDir, c.name, ".cache", "manifests", "odh-manifests")
+		if config.Status.Caches[0].LocalPath != localPath {
+			t.Fatalf("%v: LocalPath; got %v; want %v", c.name, config.Status.Caches[0].LocalPath, localPath)
+		}
+	}
+
+	if proxied != "http://manifests.example.com/manifests.tar.gz" {
+		t.Errorf("expected the proxy to be used for the repo; proxied %q", proxied)
+	}
+}
+
+func TestRepoAuth_Authorization(t *testing.T) {
+	testCases := map[string]*repoAuth{
+		"":                           nil,
+		"Bearer abc":                 {token: "abc", username: "ignored"},
+		"Basic dXNlcjpwYXNzd29yZA==": {username: "user", password: "password"},
+	}
+	for expected, auth := range testCases {
+		if actual := auth.authorization(); actual != expected {
+			t.Errorf("authorization; got %q; want %q", actual, expected)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package kfconfig
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultRevisionTimeout is how long checking the revision of a repo not setting a Timeout may take,
+// so a remote which doesn't respond doesn't hold up checking the other repos.
+const defaultRevisionTimeout = time.Minute
+
+// ResolveRepoRevision returns the revision the repo source currently points to without fetching it
+// into the cache, so it can be compared with the ResolvedRevision recorded by SyncCache.
+// This is the commit SHA for git repositories, the manifest digest for OCI artifacts, a digest of
+// the data for ConfigMaps and Secrets and the ETag, or a digest of the tarball, for http(s) URIs.
+// Local directories have no revision and return an empty string.
+// Unless the repo sets a Timeout, checking the revision times out after defaultRevisionTimeout.
+func (c *KfConfig) ResolveRepoRevision(r Repo) (string, error) {
+	return c.ResolveRepoRevisionSince(r, "", time.Time{})
+}
+
+// ResolveRepoRevisionSince is ResolveRepoRevision for a repo known to be at the revision when it was
+// last checked. http(s) sources returning neither an ETag nor a Last-Modified header are only
+// downloaded if the server doesn't answer a conditional request with Not Modified.
+func (c *KfConfig) ResolveRepoRevisionSince(r Repo, known string, lastChecked time.Time) (string, error) {
+	if r.Timeout == nil {
+		r.Timeout = &metav1.Duration{Duration: defaultRevisionTimeout}
+	}
+	if IsObjectURI(r.URI) {
+		data, err := c.readObjectSource(r)
+		if err != nil {
+			return "", err
+		}
+		return objectDigest(data), nil
+	}
+	if fi, err := os.Stat(r.URI); err == nil && fi.Mode().IsDir() {
+		return "", nil
+	}
+
+	auth, err := c.getRepoAuth(r)
+	if err != nil {
+		return "", err
+	}
+	if IsGitURI(r.URI) {
+		return resolveGitRevision(r, auth)
+	}
+	hclient, err := newRepoHTTPClient(r, auth)
+	if err != nil {
+		return "", err
+	}
+	if IsOCIURI(r.URI) {
+		return resolveOCIDigest(hclient, auth, r.URI)
+	}
+
+	// Servers return the ETag or Last-Modified on a HEAD request; fall back to downloading the tarball
+	// if they don't and it was modified since it was last checked.
+	if resp, err := doRepoRequest(hclient, auth, "HEAD", r.URI, nil); err == nil {
+		resp.Body.Close()
+		if revision := httpHeaderRevision(resp); resp.StatusCode == http.StatusOK && revision != "" {
+			return revision, nil
+		}
+	}
+	header := http.Header{}
+	if known != "" && !lastChecked.IsZero() {
+		header.Set("If-Modified-Since", lastChecked.UTC().Format(http.TimeFormat))
+	}
+	resp, err := doRepoRequest(hclient, auth, "GET", r.URI, header)
+	if err != nil {
+		return "", &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't download URI %v: %v", r.URI, err),
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && known != "" {
+		return known, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't download URI %v: %v", r.URI, resp.Status),
+		}
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", &kfapis.KfError{
+			Code:    int(kfapis.INVALID_ARGUMENT),
+			Message: fmt.Sprintf("couldn't download URI %v: %v", r.URI, err),
+		}
+	}
+	return httpRevision(resp, body), nil
+}
+
+func doRepoRequest(client *http.Client, auth *repoAuth, method string, uri string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k := range header {
+		req.Header.Set(k, header.Get(k))
+	}
+	req.Header.Set("User-Agent", "kfctl")
+	if authorization := auth.authorization(); authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return client.Do(req)
+}
+
+// httpRevision returns the ETag or the Last-Modified time of a downloaded tarball, or a digest of
+// its content if the server returns neither.
+func httpRevision(resp *http.Response, body []byte) string {
+	if revision := httpHeaderRevision(resp); revision != "" {
+		return revision
+	}
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+}
+
+// httpHeaderRevision returns the ETag of the response or else its Last-Modified time, if any.
+func httpHeaderRevision(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return etag
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		return "last-modified:" + lastModified
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package kfconfig
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveRepoRevision(t *testing.T) {
+	tarball := []byte("manifests")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/etag.tar.gz":
+			w.Header().Set("ETag", `"v1"`)
+			w.Write(tarball)
+		case "/no-etag.tar.gz":
+			w.Write(tarball)
+		case "/last-modified.tar.gz":
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			w.Write(tarball)
+		case "/conditional.tar.gz":
+			// Only answers conditional requests, so the tarball must not be downloaded.
+			if r.Method == http.MethodHead || r.Header.Get("If-Modified-Since") == "" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	manifest, _ := json.Marshal(ociManifest{MediaType: ociManifestMediaType})
+	registry := httptest.NewServer(&fakeRegistry{
+		repository: "opendatahub/odh-manifests",
+		tag:        "v1.0",
+		manifest:   manifest,
+	})
+	defer registry.Close()
+	registryHost := strings.TrimPrefix(registry.URL, "http://")
+
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	type testCase struct {
+		name             string
+		repo             Repo
+		known            string
+		lastChecked      time.Time
+		expectedRevision string
+		expectedErr      bool
+	}
+
+	testCases := []testCase{
+		{
+			name:             "etag",
+			repo:             Repo{URI: server.URL + "/etag.tar.gz"},
+			expectedRevision: `"v1"`,
+		},
+		{
+			name:             "no etag",
+			repo:             Repo{URI: server.URL + "/no-etag.tar.gz"},
+			expectedRevision: ociDigest(tarball),
+		},
+		{
+			name:             "last modified",
+			repo:             Repo{URI: server.URL + "/last-modified.tar.gz"},
+			expectedRevision: "last-modified:Mon, 02 Jan 2006 15:04:05 GMT",
+		},
+		{
+			name:             "not modified",
+			repo:             Repo{URI: server.URL + "/conditional.tar.gz"},
+			known:            ociDigest(tarball),
+			lastChecked:      time.Now(),
+			expectedRevision: ociDigest(tarball),
+		},
+		{
+			name:        "not modified without known revision",
+			repo:        Repo{URI: server.URL + "/conditional.tar.gz"},
+			expectedErr: true,
+		},
+		{
+			name:        "missing",
+			repo:        Repo{URI: server.URL + "/missing.tar.gz"},
+			expectedErr: true,
+		},
+		{
+			name:             "oci tag",
+			repo:             Repo{URI: "oci://" + registryHost + "/opendatahub/odh-manifests:v1.0"},
+			expectedRevision: ociDigest(manifest),
+		},
+		{
+			name:             "local dir",
+			repo:             Repo{URI: testDir},
+			expectedRevision: "",
+		},
+	}
+
+	if _, err := exec.LookPath("git"); err == nil {
+		bareDir, firstCommit, secondCommit := newGitRepo(t, testDir)
+		testCases = append(testCases,
+			testCase{
+				name:             "git head",
+				repo:             Repo{URI: "file://" + bareDir},
+				expectedRevision: secondCommit,
+			},
+			testCase{
+				name:             "git tag",
+				repo:             Repo{URI: "file://" + bareDir, Ref: "v1"},
+				expectedRevision: firstCommit,
+			},
+			testCase{
+				name:        "git missing ref",
+				repo:        Repo{URI: "file://" + bareDir, Ref: "v3"},
+				expectedErr: true,
+			},
+		)
+	}
+
+	for _, c := range testCases {
+		c.repo.Name = "manifests"
+		config := &KfConfig{}
+		revision, err := config.ResolveRepoRevisionSince(c.repo, c.known, c.lastChecked)
+		if c.expectedErr {
+			if err == nil {
+				t.Fatalf("%v: expected an error resolving %v", c.name, c.repo.URI)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: could not resolve revision; %v", c.name, err)
+		}
+		if revision != c.expectedRevision {
+			t.Fatalf("%v: revision; got %v; want %v", c.name, revision, c.expectedRevision)
+		}
+	}
+}
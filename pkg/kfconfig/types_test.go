@@ -15,8 +15,12 @@
 package kfconfig
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"github.com/ghodss/yaml"
+	kfapis "github.com/opendatahub-io/opendatahub-operator/apis"
 	"github.com/google/go-cmp/cmp"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/log"
@@ -25,10 +29,12 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"reflect"
 	"sigs.k8s.io/kustomize/v3/pkg/types"
+	"strings"
 	"testing"
 )
 
@@ -203,6 +209,169 @@ func TestSyncCache(t *testing.T) {
 
 }
 
+// newGitRepo creates a bare repository in dir with a first commit tagged v1 and a second commit
+// on the default branch. It returns the path of the repository and the SHAs of both commits.
+func newGitRepo(t *testing.T, dir string) (string, string, string) {
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v; %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	workDir := path.Join(dir, "work")
+	bareDir := path.Join(dir, "manifests.git")
+	os.MkdirAll(workDir, os.ModePerm)
+	git(workDir, "init", "--quiet")
+	ioutil.WriteFile(path.Join(workDir, "version"), []byte("v1"), os.ModePerm)
+	git(workDir, "add", "version")
+	git(workDir, "commit", "--quiet", "-m", "v1")
+	git(workDir, "tag", "v1")
+	firstCommit := git(workDir, "rev-parse", "HEAD")
+	ioutil.WriteFile(path.Join(workDir, "version"), []byte("v2"), os.ModePerm)
+	git(workDir, "commit", "--quiet", "-am", "v2")
+	secondCommit := git(workDir, "rev-parse", "HEAD")
+	git(dir, "clone", "--quiet", "--bare", workDir, bareDir)
+	return bareDir, firstCommit, secondCommit
+}
+
+func TestSyncCache_Git(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	bareDir, firstCommit, secondCommit := newGitRepo(t, testDir)
+
+	type testCase struct {
+		name             string
+		ref              string
+		expectedRevision string
+		expectedVersion  string
+	}
+
+	testCases := []testCase{
+		{
+			name:             "head",
+			ref:              "",
+			expectedRevision: secondCommit,
+			expectedVersion:  "v2",
+		},
+		{
+			name:             "tag",
+			ref:              "v1",
+			expectedRevision: firstCommit,
+			expectedVersion:  "v1",
+		},
+		{
+			name:             "commit",
+			ref:              firstCommit,
+			expectedRevision: firstCommit,
+			expectedVersion:  "v1",
+		},
+	}
+
+	for _, c := range testCases {
+		repoName := "manifests"
+		config := &KfConfig{
+			Spec: KfConfigSpec{
+				AppDir: path.Join(testDir, c.name),
+				Repos: []Repo{{
+					Name: repoName,
+					URI:  "file://" + bareDir,
+					Ref:  c.ref,
+				}},
+			},
+			Status: Status{
+				Caches: []Cache{{
+					Name:      repoName,
+					LocalPath: "stale",
+				}},
+			},
+		}
+
+		if err := config.SyncCache(); err != nil {
+			t.Fatalf("%v: could not sync cache; %v", c.name, err)
+		}
+
+		expected := []Cache{{
+			Name:             repoName,
+			LocalPath:        path.Join(testDir, c.name, ".cache", repoName),
+			ResolvedRevision: c.expectedRevision,
+		}}
+		if !reflect.DeepEqual(config.Status.Caches, expected) {
+			t.Fatalf("%v: caches; got %+v; want %+v", c.name, config.Status.Caches, expected)
+		}
+
+		version, err := ioutil.ReadFile(path.Join(expected[0].LocalPath, "version"))
+		if err != nil {
+			t.Fatalf("%v: could not read checked out file; %v", c.name, err)
+		}
+		if string(version) != c.expectedVersion {
+			t.Fatalf("%v: checked out version; got %v; want %v", c.name, string(version), c.expectedVersion)
+		}
+	}
+}
+
+func TestSyncCache_GitOptionInjection(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+
+	bareDir, _, _ := newGitRepo(t, testDir)
+	pwned := path.Join(testDir, "PWNED")
+
+	testCases := []Repo{
+		{Name: "ref", URI: "file://" + bareDir, Ref: "--upload-pack=touch " + pwned + ";false"},
+		{Name: "uri", URI: "git::--upload-pack=touch " + pwned + ";false"},
+	}
+	for _, r := range testCases {
+		config := &KfConfig{
+			Spec: KfConfigSpec{
+				AppDir: path.Join(testDir, r.Name),
+				Repos:  []Repo{r},
+			},
+		}
+		err := config.SyncCache()
+		if kfErr, ok := err.(*kfapis.KfError); !ok || kfErr.Code != int(kfapis.INVALID_ARGUMENT) {
+			t.Errorf("%v: expected an invalid argument error; got %v", r.Name, err)
+		}
+		if _, err := config.ResolveRepoRevision(r); err == nil {
+			t.Errorf("%v: expected resolving the revision to fail", r.Name)
+		}
+		if _, err := os.Stat(pwned); err == nil {
+			t.Fatalf("%v: git ran the injected command", r.Name)
+		}
+	}
+}
+
+func TestIsGitURI(t *testing.T) {
+	testCases := map[string]bool{
+		"git::https://github.com/opendatahub-io/odh-manifests":           true,
+		"https://github.com/opendatahub-io/odh-manifests.git":            true,
+		"git@github.com:opendatahub-io/odh-manifests.git":                true,
+		"ssh://git@github.com/opendatahub-io/odh-manifests":              true,
+		"file:///opt/manifests/odh-manifests.tar.gz":                     false,
+		"https://github.com/opendatahub-io/odh-manifests/tarball/master": false,
+		"/opt/manifests": false,
+	}
+	for uri, expected := range testCases {
+		if actual := IsGitURI(uri); actual != expected {
+			t.Errorf("IsGitURI(%v); got %v; want %v", uri, actual, expected)
+		}
+	}
+}
+
 type FakePluginSpec struct {
 	Param     string `json:"param,omitempty"`
 	BoolParam bool   `json:"boolParam,omitempty"`
@@ -1230,3 +1399,97 @@ func Pformat(value interface{}) (string, error) {
 	}
 	return string(valueJson), nil
 }
+
+// newTarball returns a gzipped tarball of the entries; regular files hold their name.
+func newTarball(t *testing.T, entries ...tar.Header) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for _, header := range entries {
+		content := []byte(header.Name)
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(content))
+		}
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatalf("failed to write tar header; %v", err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			tw.Write(content)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestUntar(t *testing.T) {
+	testDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(testDir)
+	cacheDir := path.Join(testDir, "cache")
+	os.MkdirAll(cacheDir, 0755)
+
+	type testCase struct {
+		name        string
+		tarball     []byte
+		expectedErr bool
+	}
+
+	testCases := []testCase{
+		{
+			name: "manifests",
+			tarball: newTarball(t,
+				tar.Header{Name: "manifests/", Mode: 0755, Typeflag: tar.TypeDir},
+				tar.Header{Name: "manifests/kustomization.yaml", Mode: 04755, Typeflag: tar.TypeReg},
+			),
+		},
+		{
+			name:        "parent",
+			tarball:     newTarball(t, tar.Header{Name: "../evil", Mode: 0644, Typeflag: tar.TypeReg}),
+			expectedErr: true,
+		},
+		{
+			name:        "nested-parent",
+			tarball:     newTarball(t, tar.Header{Name: "manifests/../../evil", Mode: 0644, Typeflag: tar.TypeReg}),
+			expectedErr: true,
+		},
+		{
+			name:        "absolute",
+			tarball:     newTarball(t, tar.Header{Name: path.Join(testDir, "evil"), Mode: 0644, Typeflag: tar.TypeReg}),
+			expectedErr: true,
+		},
+		{
+			name: "links",
+			tarball: newTarball(t,
+				tar.Header{Name: "manifests/symlink", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink},
+				tar.Header{Name: "manifests/hardlink", Linkname: "/etc/passwd", Typeflag: tar.TypeLink},
+			),
+		},
+	}
+
+	for _, c := range testCases {
+		err := untar(c.tarball, cacheDir)
+		if c.expectedErr {
+			if err == nil {
+				t.Errorf("%v: expected an error", c.name)
+			}
+		} else if err != nil {
+			t.Errorf("%v: unexpected error; %v", c.name, err)
+		}
+	}
+
+	if _, err := os.Stat(path.Join(testDir, "evil")); !os.IsNotExist(err) {
+		t.Errorf("expected no file outside of the cache directory; got %v", err)
+	}
+	for _, link := range []string{"symlink", "hardlink"} {
+		if _, err := os.Lstat(path.Join(cacheDir, "manifests", link)); !os.IsNotExist(err) {
+			t.Errorf("expected %v to be skipped; got %v", link, err)
+		}
+	}
+	info, err := os.Stat(path.Join(cacheDir, "manifests", "kustomization.yaml"))
+	if err != nil {
+		t.Fatalf("expected the manifests to be extracted; %v", err)
+	}
+	if info.Mode() != 0755 {
+		t.Errorf("expected the mode of the manifests to be 0755; got %v", info.Mode())
+	}
+}
@@ -68,6 +68,9 @@ const (
 	SetAnnotation              = "set-kubeflow-annotation"
 	KfDefInstance              = "kfdef-instance"
 	InstallByOperator          = "install-by-operator"
+	// SelectedApplications lists the applications to generate and apply, separated by commas,
+	// when only some of them need to be rendered again.
+	SelectedApplications = "applications"
 )
 
 func generateRandStr(length int) string {
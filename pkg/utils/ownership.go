@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cenkalti/backoff"
+	log "github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The labels the resources managed for a KfDef are labeled with, along with the kfdef-instance
+// annotation. Unlike the annotation, they can be used in label selectors.
+const (
+	KfDefNameLabel        = KfDefAnnotation + "/kfdef-name"
+	KfDefNamespaceLabel   = KfDefAnnotation + "/kfdef-namespace"
+	KfDefApplicationLabel = KfDefAnnotation + "/application"
+)
+
+// KfDefInstanceKey returns the value of the kfdef-instance annotation of the resources managed
+// for the KfDef.
+func KfDefInstanceKey(name string, namespace string) string {
+	return strings.Join([]string{name, namespace}, ".")
+}
+
+// ParseKfDefInstanceKey returns the name and namespace of the KfDef of a kfdef-instance annotation.
+// The key is split at its last dot, since names can contain dots but namespaces can't.
+func ParseKfDefInstanceKey(key string) (string, string, bool) {
+	i := strings.LastIndex(key, ".")
+	if i <= 0 || i == len(key)-1 {
+		return "", "", false
+	}
+	return key[:i], key[i+1:], true
+}
+
+// OwnershipLabels returns the labels of the resources managed for the application of the KfDef,
+// or for any of its applications if app is empty.
+func OwnershipLabels(name string, namespace string, app string) map[string]string {
+	labels := map[string]string{
+		KfDefNameLabel:      LabelValue(name),
+		KfDefNamespaceLabel: namespace,
+	}
+	if app != "" {
+		labels[KfDefApplicationLabel] = LabelValue(app)
+	}
+	return labels
+}
+
+// LabelValue returns the value if it's a valid label value, or else one made of its prefix and
+// of its hash, e.g. for the names of KfDefs longer than 63 characters.
+func LabelValue(value string) string {
+	if len(validation.IsValidLabelValue(value)) == 0 {
+		return value
+	}
+	sum := sha256.Sum256([]byte(value))
+	hash := hex.EncodeToString(sum[:])[:10]
+	prefix := strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf && (r == '-' || r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, value)
+	if len(prefix) > validation.LabelValueMaxLength-len(hash)-1 {
+		prefix = prefix[:validation.LabelValueMaxLength-len(hash)-1]
+	}
+	prefix = strings.TrimFunc(prefix, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	})
+	if prefix == "" {
+		return hash
+	}
+	return prefix + "-" + hash
+}
+
+// ObjectRef identifies an object by kind, namespace and name.
+type ObjectRef struct {
+	schema.GroupVersionKind
+	Namespace string
+	Name      string
+}
+
+// NewObjectRef returns the reference of the object.
+func NewObjectRef(obj *unstructured.Unstructured) ObjectRef {
+	return ObjectRef{GroupVersionKind: obj.GroupVersionKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+}
+
+// ObjectKey identifies an object by group, kind, namespace and name. Unlike an ObjectRef, it's
+// the same whichever version the object is served in.
+type ObjectKey struct {
+	schema.GroupKind
+	Namespace string
+	Name      string
+}
+
+// Key returns the key of the referenced object.
+func (r ObjectRef) Key() ObjectKey {
+	return ObjectKey{GroupKind: r.GroupKind(), Namespace: r.Namespace, Name: r.Name}
+}
+
+// DeleteOwnedResources deletes the objects of the kinds matching the ownership labels, except
+// the ones to keep, e.g. the resources an application still renders when pruning it. Namespaced
+// kinds are listed in all namespaces. Kinds the cluster doesn't serve are skipped. A kind can be
+// given in several versions, e.g. the one rendered and the one rendered at the last apply, so the
+// objects are kept, and deleted, whichever version they are listed in.
+// It returns the objects deleted.
+func DeleteOwnedResources(ctx context.Context, kubeclient client.Client, kinds []schema.GroupVersionKind,
+	labels map[string]string, keep map[ObjectKey]bool) ([]ObjectRef, error) {
+	deleted := []ObjectRef{}
+	seen := map[ObjectKey]bool{}
+	for _, gvk := range kinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		err := kubeclient.List(ctx, list, client.MatchingLabels(labels))
+		if meta.IsNoMatchError(err) || k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return deleted, err
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			obj.SetGroupVersionKind(gvk)
+			ref := NewObjectRef(obj)
+			if keep[ref.Key()] || seen[ref.Key()] || !obj.GetDeletionTimestamp().IsZero() {
+				continue
+			}
+			seen[ref.Key()] = true
+			log.Infof("Deleting %v %v/%v labeled %v", gvk.Kind, ref.Namespace, ref.Name, labels)
+			if err := kubeclient.Delete(ctx, obj); err != nil && !k8serrors.IsNotFound(err) {
+				return deleted, err
+			}
+			deleted = append(deleted, ref)
+		}
+	}
+	return deleted, nil
+}
+
+// SortKinds sorts the kinds in place by the order, the unknown kinds last, by kind.
+func SortKinds(kinds []schema.GroupVersionKind, ordering SortOrder) {
+	o := make(map[string]int, len(ordering))
+	for v, k := range ordering {
+		o[k] = v
+	}
+	sort.SliceStable(kinds, func(i, j int) bool {
+		first, aok := o[kinds[i].Kind]
+		second, bok := o[kinds[j].Kind]
+		if aok != bok {
+			return aok
+		}
+		if !aok || first == second {
+			return kinds[i].Kind < kinds[j].Kind
+		}
+		return first < second
+	})
+}
+
+// WaitForDeleted polls until the objects are deleted, e.g. the ones deleted by
+// DeleteOwnedResources, or the timeout expires.
+func WaitForDeleted(ctx context.Context, kubeclient client.Client, refs []ObjectRef, timeout time.Duration) error {
+	interval := 5 * time.Second
+	for _, ref := range refs {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(ref.GroupVersionKind)
+		b := backoff.WithMaxRetries(backoff.NewConstantBackOff(interval), uint64(timeout/interval+1))
+		err := backoff.Retry(func() error {
+			err := kubeclient.Get(ctx, k8stypes.NamespacedName{Name: ref.Name, Namespace: ref.Namespace}, obj)
+			if !k8serrors.IsNotFound(err) {
+				return fmt.Errorf("deleted resource is not cleaned up yet")
+			}
+			return nil
+		}, b)
+		if err != nil {
+			return fmt.Errorf("timed out waiting for %v %v/%v to be deleted: %v", ref.Kind, ref.Namespace, ref.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	appsv1beta2 "k8s.io/api/apps/v1beta2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestParseKfDefInstanceKey(t *testing.T) {
+	tests := []struct {
+		key       string
+		name      string
+		namespace string
+		ok        bool
+	}{
+		{key: "opendatahub.odh", name: "opendatahub", namespace: "odh", ok: true},
+		{key: "odh.v1.2.odh", name: "odh.v1.2", namespace: "odh", ok: true},
+		{key: KfDefInstanceKey("odh.v1.2", "odh"), name: "odh.v1.2", namespace: "odh", ok: true},
+		{key: "opendatahub"},
+		{key: "opendatahub."},
+		{key: ".odh"},
+		{key: ""},
+	}
+	for _, test := range tests {
+		name, namespace, ok := ParseKfDefInstanceKey(test.key)
+		if name != test.name || namespace != test.namespace || ok != test.ok {
+			t.Errorf("ParseKfDefInstanceKey(%q) = %q, %q, %v; expected %q, %q, %v",
+				test.key, name, namespace, ok, test.name, test.namespace, test.ok)
+		}
+	}
+}
+
+func TestOwnershipLabels(t *testing.T) {
+	labels := OwnershipLabels("odh.v1.2", "odh", "dashboard")
+	expected := map[string]string{
+		KfDefNameLabel:        "odh.v1.2",
+		KfDefNamespaceLabel:   "odh",
+		KfDefApplicationLabel: "dashboard",
+	}
+	for k, v := range expected {
+		if labels[k] != v {
+			t.Errorf("label %v: expected %q, got %q", k, v, labels[k])
+		}
+	}
+	if labels := OwnershipLabels("odh", "odh", ""); len(labels) != 2 {
+		t.Errorf("expected no application label, got %v", labels)
+	}
+	long := OwnershipLabels(strings.Repeat("a", 64), "odh", "dashboard")
+	other := OwnershipLabels(strings.Repeat("a", 63)+"b", "odh", "dashboard")
+	if long[KfDefNameLabel] == other[KfDefNameLabel] {
+		t.Errorf("expected different labels for different long names, got %q", long[KfDefNameLabel])
+	}
+}
+
+func TestLabelValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "odh.v1.2", expected: "odh.v1.2"},
+		{value: strings.Repeat("a", 63), expected: strings.Repeat("a", 63)},
+		{value: strings.Repeat("a", 64)},
+		{value: strings.Repeat("a", 51) + ".-b"},
+		{value: "my app/v1"},
+		{value: "-/-"},
+	}
+	for _, test := range tests {
+		value := LabelValue(test.value)
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			t.Errorf("invalid label value %q for %q: %v", value, test.value, errs)
+		}
+		if test.expected != "" && value != test.expected {
+			t.Errorf("expected %q for %q, got %q", test.expected, test.value, value)
+		}
+		if value != LabelValue(test.value) {
+			t.Errorf("expected the same label value for %q", test.value)
+		}
+	}
+}
+
+func TestDeleteOwnedResources(t *testing.T) {
+	labels := OwnershipLabels("opendatahub", "odh", "dashboard")
+	configMap := func(namespace string, name string, labels map[string]string) *v1.ConfigMap {
+		return &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+	}
+	otherLabels := OwnershipLabels("opendatahub", "odh", "notebooks")
+	kubeclient := fake.NewClientBuilder().WithObjects(
+		configMap("odh", "rendered", labels),
+		configMap("odh", "removed", labels),
+		configMap("other", "removed", labels),
+		configMap("odh", "notebooks", otherLabels),
+		configMap("odh", "unmanaged", nil),
+	).Build()
+
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	keep := map[ObjectKey]bool{{GroupKind: gvk.GroupKind(), Namespace: "odh", Name: "rendered"}: true}
+	deleted, err := DeleteOwnedResources(context.TODO(), kubeclient, []schema.GroupVersionKind{gvk}, labels, keep)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 2 {
+		t.Errorf("expected 2 deleted config maps, got %v", deleted)
+	}
+
+	remaining := &v1.ConfigMapList{}
+	if err := kubeclient.List(context.TODO(), remaining); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, cm := range remaining.Items {
+		names = append(names, cm.Namespace+"/"+cm.Name)
+	}
+	if strings.Join(names, ",") != "odh/notebooks,odh/rendered,odh/unmanaged" {
+		t.Errorf("unexpected remaining config maps %v", names)
+	}
+	// the deleted config maps are gone at once with the fake client
+	if err := WaitForDeleted(context.TODO(), kubeclient, deleted, time.Minute); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDeleteOwnedResources_VersionChanged(t *testing.T) {
+	labels := OwnershipLabels("opendatahub", "odh", "dashboard")
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: "odh", Name: name, Labels: labels}
+	}
+	// the cluster serves the same deployments in both versions
+	kubeclient := fake.NewClientBuilder().WithObjects(
+		&appsv1.Deployment{ObjectMeta: meta("rendered")},
+		&appsv1.Deployment{ObjectMeta: meta("removed")},
+		&appsv1beta2.Deployment{ObjectMeta: meta("rendered")},
+		&appsv1beta2.Deployment{ObjectMeta: meta("removed")},
+	).Build()
+
+	// the manifests moved from apps/v1beta2 to apps/v1: the kind of the last apply is listed too
+	previous := schema.GroupVersionKind{Group: "apps", Version: "v1beta2", Kind: "Deployment"}
+	rendered := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	keep := map[ObjectKey]bool{
+		ObjectRef{GroupVersionKind: rendered, Namespace: "odh", Name: "rendered"}.Key(): true,
+	}
+	deleted, err := DeleteOwnedResources(context.TODO(), kubeclient, []schema.GroupVersionKind{rendered, previous}, labels, keep)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0].Name != "removed" {
+		t.Errorf("expected only the removed deployment to be deleted, got %v", deleted)
+	}
+	if err := kubeclient.Get(context.TODO(), client.ObjectKey{Namespace: "odh", Name: "rendered"}, &appsv1beta2.Deployment{}); err != nil {
+		t.Errorf("expected the rendered deployment to be kept: %v", err)
+	}
+}
+
+func TestSortKinds(t *testing.T) {
+	kinds := []schema.GroupVersionKind{
+		{Group: "dashboard.opendatahub.io", Version: "v1", Kind: "OdhApplication"},
+		{Version: "v1", Kind: "Secret"},
+		{Group: "apps", Version: "v1", Kind: "Deployment"},
+		{Group: "argoproj.io", Version: "v1alpha1", Kind: "Workflow"},
+		{Version: "v1", Kind: "Service"},
+	}
+	SortKinds(kinds, UninstallOrder)
+	sorted := []string{}
+	for _, gvk := range kinds {
+		sorted = append(sorted, gvk.Kind)
+	}
+	if strings.Join(sorted, ",") != "Service,Deployment,Secret,OdhApplication,Workflow" {
+		t.Errorf("unexpected order %v", sorted)
+	}
+}